@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"go-graphdl/crud/gqlerr"
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Author contains information about the author of quotes
+type Author = store.Author
+
+var authorType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"bio": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	},
+)
+
+func init() {
+	// Added here because the connection refers back to Quote, whose
+	// author field refers to Author.
+	authorType.AddFieldConfig("quotes", &graphql.Field{
+		Type:        graphql.NewNonNull(quoteConnectionType),
+		Description: "Page through the author's quotes",
+		Args:        mergeArgs(listArgs, connectionArgs),
+		Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+			l, err := loadersFrom(p.Context)
+			if err != nil {
+				return nil, err
+			}
+			query := queryFrom(p.Args)
+			query.Author = p.Source.(Author).Name
+			list, err := l.quotes.List(p.Context, query)
+			if err != nil {
+				return nil, err
+			}
+			return paginate(list, query.Order, pageArgsFrom(p.Args))
+		}),
+	})
+}
+
+// resolveQuoteAuthor resolves Quote.author through the request's loader,
+// so that a list of quotes costs one lookup of their authors.
+func resolveQuoteAuthor(p graphql.ResolveParams) (interface{}, error) {
+	name := p.Source.(Quote).Author
+	if name == "" {
+		return nil, nil
+	}
+	l, err := loadersFrom(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	return l.authors.Load(p.Context, name), nil
+}
+
+// loaders hold the DataLoaders of one request. They cache what they load,
+// so every request needs its own; see withLoaders.
+type loaders struct {
+	quotes  store.QuoteStore
+	authors *authorLoader
+}
+
+type loadersKey struct{}
+
+var errNoLoaders = errors.New("no loaders in the request context")
+
+// withLoaders returns a context carrying new loaders reading from quotes.
+func withLoaders(ctx context.Context, quotes store.QuoteStore) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		quotes:  quotes,
+		authors: newAuthorLoader(quotes.AuthorsByName),
+	})
+}
+
+func loadersFrom(ctx context.Context) (*loaders, error) {
+	if ctx == nil {
+		return nil, errNoLoaders
+	}
+	l, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return nil, errNoLoaders
+	}
+	return l, nil
+}
+
+// loaderMiddleware gives every request its own loaders.
+func loaderMiddleware(quotes store.QuoteStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withLoaders(r.Context(), quotes)))
+	})
+}
+
+// authorLoader batches and caches the lookup of authors by name.
+//
+// Load does not look the author up but returns a thunk. graphql-go only
+// calls thunks once it has resolved every field it can, so by the time
+// the first one runs the loader has collected all the names on that level
+// of the response, and fetches them in one batch.
+type authorLoader struct {
+	fetch func(ctx context.Context, names []string) ([]Author, error)
+
+	mu      sync.Mutex
+	results map[string]*authorResult
+	pending []string
+	batches int
+}
+
+type authorResult struct {
+	done   bool
+	author *Author
+	err    error
+}
+
+func newAuthorLoader(fetch func(ctx context.Context, names []string) ([]Author, error)) *authorLoader {
+	return &authorLoader{fetch: fetch, results: map[string]*authorResult{}}
+}
+
+// Load returns a thunk resolving to the author called name, or nil if
+// there is none.
+func (l *authorLoader) Load(ctx context.Context, name string) func() (interface{}, error) {
+	l.mu.Lock()
+	r, ok := l.results[name]
+	if !ok {
+		r = &authorResult{}
+		l.results[name] = r
+		l.pending = append(l.pending, name)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !r.done {
+			l.dispatchLocked(ctx)
+		}
+		if r.author == nil {
+			return nil, r.err
+		}
+		return *r.author, r.err
+	}
+}
+
+// dispatchLocked fetches the pending names. It must be called with l.mu
+// held.
+func (l *authorLoader) dispatchLocked(ctx context.Context) {
+	names := l.pending
+	l.pending = nil
+	l.batches++
+
+	authors, err := l.fetch(ctx, names)
+	byName := make(map[string]Author, len(authors))
+	for _, a := range authors {
+		byName[a.Name] = a
+	}
+	for _, name := range names {
+		r := l.results[name]
+		r.done, r.err = true, err
+		if a, ok := byName[name]; ok {
+			r.author = &a
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/gqlerr"
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// countingStore records the batches of names looked up.
+type countingStore struct {
+	store.QuoteStore
+	batches [][]string
+}
+
+func (s *countingStore) AuthorsByName(ctx context.Context, names []string) ([]Author, error) {
+	batch := append([]string(nil), names...)
+	sort.Strings(batch)
+	s.batches = append(s.batches, batch)
+	return s.QuoteStore.AuthorsByName(ctx, names)
+}
+
+func authorSchema(t *testing.T) (graphql.Schema, *countingStore) {
+	t.Helper()
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	quotes := &countingStore{QuoteStore: store.NewMemory(
+		Quote{ID: 1, Quote: "One", Author: "Ann", Date: date},
+		Quote{ID: 2, Quote: "Two", Author: "Bob", Date: date},
+		Quote{ID: 3, Quote: "Three", Author: "Ann", Date: date},
+		Quote{ID: 4, Quote: "Four", Date: date},
+		Quote{ID: 5, Quote: "Five", Author: "Cy", Date: date},
+	)}
+	schema, err := newSchema(quotes, store.NewSequence(5), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, quotes
+}
+
+func run(t *testing.T, schema graphql.Schema, quotes store.QuoteStore, query string) map[string]interface{} {
+	t.Helper()
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: query,
+		Context:       withLoaders(context.Background(), quotes),
+	})
+	if len(result.Errors) > 0 {
+		t.Fatalf("%s: %v", query, result.Errors)
+	}
+	return result.Data.(map[string]interface{})
+}
+
+func TestQuoteAuthorIsBatched(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		query   string
+		batches [][]string
+	}{
+		{
+			"list",
+			`{ list { author { name } } }`,
+			[][]string{{"Ann", "Bob", "Cy"}},
+		},
+		{
+			"several root fields",
+			`{ quote(id: 2) { author { name } } list { author { id } } }`,
+			[][]string{{"Ann", "Bob", "Cy"}},
+		},
+		{
+			"connection",
+			`{ quotes(first: 3) { edges { node { author { name } } } } }`,
+			[][]string{{"Ann", "Bob"}},
+		},
+		{
+			// The authors of the nested quotes are already cached.
+			"nested",
+			`{ list { author { name quotes { edges { node { author { name } } } } } } }`,
+			[][]string{{"Ann", "Bob", "Cy"}},
+		},
+		{
+			"no authors",
+			`{ quote(id: 4) { author { name } } }`,
+			nil,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, quotes := authorSchema(t)
+			run(t, schema, quotes, tt.query)
+			if !reflect.DeepEqual(quotes.batches, tt.batches) {
+				t.Errorf("batches = %v, want %v", quotes.batches, tt.batches)
+			}
+		})
+	}
+}
+
+func TestQuoteAuthor(t *testing.T) {
+	schema, quotes := authorSchema(t)
+	data := run(t, schema, quotes, `{ list { id author { id name } } }`)
+
+	var got []interface{}
+	for _, q := range data["list"].([]interface{}) {
+		got = append(got, q.(map[string]interface{})["author"])
+	}
+	ann := map[string]interface{}{"id": "1", "name": "Ann"}
+	bob := map[string]interface{}{"id": "2", "name": "Bob"}
+	cy := map[string]interface{}{"id": "3", "name": "Cy"}
+	if want := []interface{}{ann, bob, ann, nil, cy}; !reflect.DeepEqual(got, want) {
+		t.Errorf("authors = %v, want %v", got, want)
+	}
+}
+
+func TestAuthorQueries(t *testing.T) {
+	schema, quotes := authorSchema(t)
+	data := run(t, schema, quotes, `{
+		ann: author(id: 1) { name quotes(orderBy: ID_DESC) { totalCount edges { node { id } } } }
+		authors { name }
+	}`)
+
+	ann := data["ann"].(map[string]interface{})
+	conn := ann["quotes"].(map[string]interface{})
+	edges := conn["edges"].([]interface{})
+	if ann["name"] != "Ann" || conn["totalCount"] != 2 || len(edges) != 2 ||
+		edges[0].(map[string]interface{})["node"].(map[string]interface{})["id"] != "3" {
+		t.Errorf("author(id: 1) = %v", ann)
+	}
+	if n := len(data["authors"].([]interface{})); n != 3 {
+		t.Errorf("%d authors, want 3", n)
+	}
+}
+
+func TestLoadersArePerRequest(t *testing.T) {
+	quotes := store.NewMemory(Quote{ID: 1, Quote: "Hi", Author: "Ann"})
+	schema, _ := newSchema(quotes, store.NewSequence(1), newEventBus())
+	handler := loaderMiddleware(quotes, graphQLHandler(schema))
+	name := func() interface{} {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, post("application/graphql", `{ quote(id: 1) { author { name } } }`))
+		var resp response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Errors) > 0 {
+			t.Fatalf("response %s: %v", rec.Body, err)
+		}
+		return resp.Data["quote"].(map[string]interface{})["author"].(map[string]interface{})["name"]
+	}
+
+	if got := name(); got != "Ann" {
+		t.Fatalf("name = %v", got)
+	}
+	renamed := "Anne"
+	quotes.UpdateAuthor(context.Background(), 1, store.AuthorPatch{Name: &renamed})
+	if got := name(); got != "Anne" {
+		t.Errorf("name after rename = %v, want Anne: the loader cache outlived its request", got)
+	}
+}
+
+func TestUpdateAuthor(t *testing.T) {
+	schema, quotes := authorSchema(t)
+	data := run(t, schema, quotes, `mutation { updateAuthor(id: 1, name: "Anne", bio: "Counts") { id name bio } }`)
+	want := map[string]interface{}{"id": "1", "name": "Anne", "bio": "Counts"}
+	if !reflect.DeepEqual(data["updateAuthor"], want) {
+		t.Errorf("updateAuthor = %v, want %v", data["updateAuthor"], want)
+	}
+	if q, _ := quotes.Get(context.Background(), 3); q.Author != "Anne" {
+		t.Errorf("quote 3 author = %q, want Anne", q.Author)
+	}
+}
+
+func TestQuoteAuthorNeedsLoaders(t *testing.T) {
+	schema, _ := authorSchema(t)
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ quote(id: 1) { author { name } } }`})
+	// The cause is only logged; clients see an internal error.
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != string(gqlerr.Internal) {
+		t.Errorf("errors = %v, want an internal error", result.Errors)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-graphdl/crud/gqlerr"
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Relay cursor connections for quotes, following
+// https://relay.dev/graphql/connections.htm
+
+type quoteEdge struct {
+	Node   Quote  `json:"node"`
+	Cursor string `json:"cursor"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type quoteConnection struct {
+	Edges      []quoteEdge `json:"edges"`
+	PageInfo   pageInfo    `json:"pageInfo"`
+	TotalCount int         `json:"totalCount"`
+}
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"startCursor":     &graphql.Field{Type: graphql.String},
+		"endCursor":       &graphql.Field{Type: graphql.String},
+	},
+})
+
+var quoteEdgeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "QuoteEdge",
+	Fields: graphql.Fields{
+		"node":   &graphql.Field{Type: graphql.NewNonNull(quoteType)},
+		"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var quoteConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "QuoteConnection",
+	Fields: graphql.Fields{
+		"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(quoteEdgeType)))},
+		"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// connectionArgs are the arguments of a connection field, on top of the
+// listArgs.
+var connectionArgs = graphql.FieldConfigArgument{
+	"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+	"after":  &graphql.ArgumentConfig{Type: graphql.String},
+	"last":   &graphql.ArgumentConfig{Type: graphql.Int},
+	"before": &graphql.ArgumentConfig{Type: graphql.String},
+}
+
+// pageArgs holds the pagination arguments; nil means not given.
+type pageArgs struct {
+	First, Last   *int
+	After, Before *string
+}
+
+func pageArgsFrom(args map[string]interface{}) pageArgs {
+	var page pageArgs
+	if v, ok := args["first"].(int); ok {
+		page.First = &v
+	}
+	if v, ok := args["last"].(int); ok {
+		page.Last = &v
+	}
+	if v, ok := args["after"].(string); ok {
+		page.After = &v
+	}
+	if v, ok := args["before"].(string); ok {
+		page.Before = &v
+	}
+	return page
+}
+
+// cursorKey is the position of a quote in an order. Cursors hold the key
+// rather than an offset, so they stay valid as quotes come and go.
+type cursorKey struct {
+	order store.Order
+	quote Quote
+}
+
+func keyOf(order store.Order, q Quote) cursorKey {
+	return cursorKey{order: order, quote: Quote{ID: q.ID, Date: q.Date}}
+}
+
+// less reports whether k sorts before other.
+func (k cursorKey) less(other cursorKey) bool {
+	return k.order.Less(k.quote, other.quote)
+}
+
+func byDate(order store.Order) bool {
+	return order == store.OrderDateAsc || order == store.OrderDateDesc
+}
+
+func (k cursorKey) encode() string {
+	s := orderNames[k.order] + ":" + strconv.FormatInt(k.quote.ID, 10)
+	if byDate(k.order) {
+		s += ":" + k.quote.Date.UTC().Format(time.RFC3339Nano)
+	}
+	return base64.StdEncoding.EncodeToString([]byte("quote:" + s))
+}
+
+func decodeCursor(order store.Order, cursor string) (cursorKey, error) {
+	invalid := gqlerr.New(gqlerr.BadUserInput, "invalid cursor %q", cursor)
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return cursorKey{}, invalid
+	}
+	parts := strings.SplitN(string(raw), ":", 4)
+	if len(parts) < 3 || parts[0] != "quote" {
+		return cursorKey{}, invalid
+	}
+	if parts[1] != orderNames[order] {
+		return cursorKey{}, gqlerr.New(gqlerr.BadUserInput, "cursor %q belongs to order %s, not %s", cursor, parts[1], orderNames[order])
+	}
+	key := cursorKey{order: order}
+	if key.quote.ID, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return cursorKey{}, invalid
+	}
+	if byDate(order) {
+		if len(parts) != 4 {
+			return cursorKey{}, invalid
+		}
+		if key.quote.Date, err = time.Parse(time.RFC3339Nano, parts[3]); err != nil {
+			return cursorKey{}, invalid
+		}
+	}
+	return key, nil
+}
+
+// paginate slices quotes, as listed by the store in order, as described
+// by the Relay spec's "Pagination algorithm".
+func paginate(quotes []Quote, order store.Order, page pageArgs) (quoteConnection, error) {
+	if (page.First != nil && *page.First < 0) || (page.Last != nil && *page.Last < 0) {
+		return quoteConnection{}, gqlerr.New(gqlerr.BadUserInput, "first and last must not be negative")
+	}
+
+	start, end := 0, len(quotes)
+	if page.After != nil {
+		key, err := decodeCursor(order, *page.After)
+		if err != nil {
+			return quoteConnection{}, err
+		}
+		start = sort.Search(len(quotes), func(i int) bool {
+			return key.less(keyOf(order, quotes[i]))
+		})
+	}
+	if page.Before != nil {
+		key, err := decodeCursor(order, *page.Before)
+		if err != nil {
+			return quoteConnection{}, err
+		}
+		end = sort.Search(len(quotes), func(i int) bool {
+			return !keyOf(order, quotes[i]).less(key)
+		})
+	}
+	if end < start {
+		end = start
+	}
+
+	// Quotes cut off by the cursors count as further pages.
+	info := pageInfo{
+		HasPreviousPage: start > 0 && page.After != nil,
+		HasNextPage:     end < len(quotes) && page.Before != nil,
+	}
+	if page.First != nil && end-start > *page.First {
+		end = start + *page.First
+		info.HasNextPage = true
+	}
+	if page.Last != nil && end-start > *page.Last {
+		start = end - *page.Last
+		info.HasPreviousPage = true
+	}
+
+	conn := quoteConnection{
+		Edges:      make([]quoteEdge, 0, end-start),
+		PageInfo:   info,
+		TotalCount: len(quotes),
+	}
+	for _, q := range quotes[start:end] {
+		conn.Edges = append(conn.Edges, quoteEdge{Node: q, Cursor: keyOf(order, q).encode()})
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// fiveQuotes returns quotes 1 to 5, each a day older than the one before,
+// so that DATE_ASC runs opposite to ID_ASC.
+func fiveQuotes() []Quote {
+	day := time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC)
+	var quotes []Quote
+	for id := int64(1); id <= 5; id++ {
+		quotes = append(quotes, Quote{ID: id, Date: day.AddDate(0, 0, -int(id))})
+	}
+	return quotes
+}
+
+func intp(n int) *int { return &n }
+
+func cursor(id int64) *string {
+	c := keyOf(store.OrderIDAsc, Quote{ID: id}).encode()
+	return &c
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       pageArgs
+		ids        []int64
+		prev, next bool
+	}{
+		{"everything", pageArgs{}, []int64{1, 2, 3, 4, 5}, false, false},
+		{"first", pageArgs{First: intp(2)}, []int64{1, 2}, false, true},
+		{"first all", pageArgs{First: intp(5)}, []int64{1, 2, 3, 4, 5}, false, false},
+		{"first more than all", pageArgs{First: intp(9)}, []int64{1, 2, 3, 4, 5}, false, false},
+		{"first zero", pageArgs{First: intp(0)}, nil, false, true},
+		{"last", pageArgs{Last: intp(2)}, []int64{4, 5}, true, false},
+		{"last zero", pageArgs{Last: intp(0)}, nil, true, false},
+		{"after", pageArgs{After: cursor(2)}, []int64{3, 4, 5}, true, false},
+		{"after last", pageArgs{After: cursor(5)}, nil, true, false},
+		{"after missing quote", pageArgs{After: cursor(9)}, nil, true, false},
+		{"after before first", pageArgs{After: cursor(0)}, []int64{1, 2, 3, 4, 5}, false, false},
+		{"first after", pageArgs{First: intp(2), After: cursor(1)}, []int64{2, 3}, true, true},
+		{"first after to end", pageArgs{First: intp(2), After: cursor(3)}, []int64{4, 5}, true, false},
+		{"before", pageArgs{Before: cursor(3)}, []int64{1, 2}, false, true},
+		{"before first", pageArgs{Before: cursor(1)}, nil, false, true},
+		{"last before", pageArgs{Last: intp(2), Before: cursor(5)}, []int64{3, 4}, true, true},
+		{"last before to start", pageArgs{Last: intp(2), Before: cursor(3)}, []int64{1, 2}, false, true},
+		{"after and before", pageArgs{After: cursor(1), Before: cursor(5)}, []int64{2, 3, 4}, true, true},
+		{"after past before", pageArgs{After: cursor(4), Before: cursor(2)}, nil, true, true},
+		{"first and last", pageArgs{First: intp(3), Last: intp(2)}, []int64{2, 3}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := paginate(fiveQuotes(), store.OrderIDAsc, tt.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int64
+			for _, edge := range conn.Edges {
+				ids = append(ids, edge.Node.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("ids = %v, want %v", ids, tt.ids)
+			}
+			if conn.PageInfo.HasPreviousPage != tt.prev || conn.PageInfo.HasNextPage != tt.next {
+				t.Errorf("hasPreviousPage, hasNextPage = %v, %v, want %v, %v",
+					conn.PageInfo.HasPreviousPage, conn.PageInfo.HasNextPage, tt.prev, tt.next)
+			}
+			if conn.TotalCount != 5 {
+				t.Errorf("totalCount = %d, want 5", conn.TotalCount)
+			}
+			if len(ids) == 0 {
+				if conn.PageInfo.StartCursor != nil || conn.PageInfo.EndCursor != nil {
+					t.Error("empty page has cursors")
+				}
+			} else if *conn.PageInfo.StartCursor != conn.Edges[0].Cursor || *conn.PageInfo.EndCursor != conn.Edges[len(ids)-1].Cursor {
+				t.Error("start and end cursors do not match the edges")
+			}
+		})
+	}
+}
+
+func TestPaginateErrors(t *testing.T) {
+	dateCursor := keyOf(store.OrderDateAsc, Quote{ID: 1}).encode()
+	bad := "not a cursor"
+	for name, page := range map[string]pageArgs{
+		"negative first": {First: intp(-1)},
+		"negative last":  {Last: intp(-1)},
+		"invalid after":  {After: &bad},
+		"invalid before": {Before: &bad},
+		"other order":    {After: &dateCursor},
+		"bad id":         {Before: strp("cXVvdGU6SURfQVNDOng=")}, // quote:ID_ASC:x
+	} {
+		if _, err := paginate(fiveQuotes(), store.OrderIDAsc, page); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func strp(s string) *string { return &s }
+
+func TestPaginateEmpty(t *testing.T) {
+	conn, err := paginate(nil, store.OrderIDAsc, pageArgs{First: intp(3)})
+	if err != nil || len(conn.Edges) != 0 || conn.TotalCount != 0 || conn.PageInfo.HasNextPage || conn.PageInfo.HasPreviousPage {
+		t.Errorf("paginate(nil) = %+v, %v", conn, err)
+	}
+}
+
+func TestQuotesConnection(t *testing.T) {
+	// Quotes 6 and 7 share a date with quote 5; the tie is broken by id.
+	quotes := fiveQuotes()
+	quotes = append(quotes, Quote{ID: 7, Date: quotes[4].Date}, Quote{ID: 6, Date: quotes[4].Date})
+	schema, err := newSchema(store.NewMemory(quotes...), store.NewSequence(7), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := func(after interface{}) (ids []interface{}, endCursor interface{}, next bool) {
+		t.Helper()
+		result := graphql.Do(graphql.Params{
+			Schema: schema,
+			RequestString: `query Page($after: String) {
+				quotes(first: 3, after: $after, orderBy: DATE_ASC) {
+					edges { cursor node { id } }
+					pageInfo { hasNextPage endCursor }
+					totalCount
+				}
+			}`,
+			VariableValues: map[string]interface{}{"after": after},
+		})
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		conn := result.Data.(map[string]interface{})["quotes"].(map[string]interface{})
+		if conn["totalCount"] != 7 {
+			t.Errorf("totalCount = %v, want 7", conn["totalCount"])
+		}
+		for _, edge := range conn["edges"].([]interface{}) {
+			ids = append(ids, edge.(map[string]interface{})["node"].(map[string]interface{})["id"])
+		}
+		info := conn["pageInfo"].(map[string]interface{})
+		return ids, info["endCursor"], info["hasNextPage"].(bool)
+	}
+
+	var got []interface{}
+	var after interface{}
+	for {
+		ids, end, next := page(after)
+		got = append(got, ids...)
+		if !next {
+			break
+		}
+		after = end
+	}
+	want := []interface{}{"5", "6", "7", "4", "3", "2", "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestPaginateDescending(t *testing.T) {
+	quotes := fiveQuotes()
+	for i, j := 0, len(quotes)-1; i < j; i, j = i+1, j-1 {
+		quotes[i], quotes[j] = quotes[j], quotes[i]
+	}
+	after := keyOf(store.OrderIDDesc, Quote{ID: 4}).encode()
+	conn, err := paginate(quotes, store.OrderIDDesc, pageArgs{First: intp(2), After: &after})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.Edges) != 2 || conn.Edges[0].Node.ID != 3 || conn.Edges[1].Node.ID != 2 || !conn.PageInfo.HasNextPage || !conn.PageInfo.HasPreviousPage {
+		t.Errorf("page after 4 = %+v", conn)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// failingStore fails where a broken database would.
+type failingStore struct {
+	store.QuoteStore
+}
+
+var errDisk = errors.New("disk on fire")
+
+func (failingStore) Create(ctx context.Context, q Quote) (Quote, error) {
+	return Quote{}, errDisk
+}
+
+func (failingStore) AuthorsByName(ctx context.Context, names []string) ([]Author, error) {
+	return nil, errDisk
+}
+
+func TestErrorShapes(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		query string
+		// errors is the JSON of the response's errors.
+		errors string
+	}{
+		{
+			"missing quote",
+			`{ quote(id: 9) { id } }`,
+			`[{"message": "quote not found", "locations": [{"line": 1, "column": 3}], "path": ["quote"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"missing author",
+			`{ author(id: 9) { id } }`,
+			`[{"message": "author not found", "locations": [{"line": 1, "column": 3}], "path": ["author"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"invalid id",
+			`{ quote(id: "one") { id } }`,
+			`[{"message": "invalid id \"one\"", "locations": [{"line": 1, "column": 3}], "path": ["quote"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"negative first",
+			`{ quotes(first: -1) { totalCount } }`,
+			`[{"message": "first and last must not be negative", "locations": [{"line": 1, "column": 3}], "path": ["quotes"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"invalid cursor",
+			`{ quotes(after: "nope") { totalCount } }`,
+			`[{"message": "invalid cursor \"nope\"", "locations": [{"line": 1, "column": 3}], "path": ["quotes"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"create fails in the store",
+			`mutation { create(quote: "New") { id } }`,
+			`[{"message": "internal error", "locations": [{"line": 1, "column": 12}], "path": ["create"], "extensions": {"code": "INTERNAL"}}]`,
+		},
+		{
+			"update missing quote",
+			`mutation { update(id: 9, quote: "Nine") { id } }`,
+			`[{"message": "quote not found", "locations": [{"line": 1, "column": 12}], "path": ["update"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"update invalid id",
+			`mutation { update(id: "-1", quote: "Nine") { id } }`,
+			`[{"message": "invalid id \"-1\"", "locations": [{"line": 1, "column": 12}], "path": ["update"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"delete missing quote",
+			`mutation { delete(id: 9) { id } }`,
+			`[{"message": "quote not found", "locations": [{"line": 1, "column": 12}], "path": ["delete"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"update missing author",
+			`mutation { updateAuthor(id: 9, bio: "Who?") { id } }`,
+			`[{"message": "author not found", "locations": [{"line": 1, "column": 12}], "path": ["updateAuthor"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"rename author to taken name",
+			`mutation { updateAuthor(id: 1, name: "Bob") { id } }`,
+			`[{"message": "author name already in use", "locations": [{"line": 1, "column": 12}], "path": ["updateAuthor"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"rename missing tag",
+			`mutation { renameTag(from: "nope", to: "new") { name } }`,
+			`[{"message": "tag not found", "locations": [{"line": 1, "column": 12}], "path": ["renameTag"], "extensions": {"code": "NOT_FOUND"}}]`,
+		},
+		{
+			"rename tag to taken tag",
+			`mutation { renameTag(from: "a", to: "b") { name } }`,
+			`[{"message": "tag already in use", "locations": [{"line": 1, "column": 12}], "path": ["renameTag"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			"merge into empty tag",
+			`mutation { mergeTags(from: ["a", "b"], into: "") { name } }`,
+			`[{"message": "tag must not be empty", "locations": [{"line": 1, "column": 12}], "path": ["mergeTags"], "extensions": {"code": "BAD_USER_INPUT"}}]`,
+		},
+		{
+			// Authors are loaded in a batch, after the list resolves.
+			"author lookup fails in the store",
+			`{ list { id author { name } } }`,
+			`[
+				{"message": "internal error", "locations": [{"line": 1, "column": 13}], "path": ["list", 0, "author"], "extensions": {"code": "INTERNAL"}},
+				{"message": "internal error", "locations": [{"line": 1, "column": 13}], "path": ["list", 1, "author"], "extensions": {"code": "INTERNAL"}}
+			]`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+			quotes := failingStore{store.NewMemory(
+				Quote{ID: 1, Quote: "One", Author: "Ann", Tags: []string{"a"}, Date: date},
+				Quote{ID: 2, Quote: "Two", Author: "Bob", Tags: []string{"b"}, Date: date},
+			)}
+			schema, err := newSchema(quotes, store.NewSequence(2), newEventBus())
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := graphql.Do(graphql.Params{
+				Schema:        schema,
+				RequestString: tt.query,
+				Context:       withLoaders(context.Background(), quotes),
+			})
+
+			var got, want interface{}
+			data, _ := json.Marshal(result.Errors)
+			json.Unmarshal(data, &got)
+			if err := json.Unmarshal([]byte(tt.errors), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("errors = %s\nwant %s", data, tt.errors)
+			}
+		})
+	}
+}
+
+func TestInternalErrorsAreLogged(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	quotes := failingStore{store.NewMemory()}
+	schema, _ := newSchema(quotes, store.NewSequence(0), newEventBus())
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `mutation { create(quote: "New") { id } }`})
+
+	if len(result.Errors) != 1 || result.Errors[0].Message != "internal error" {
+		t.Errorf("errors = %v", result.Errors)
+	}
+	if !strings.Contains(logged.String(), "disk on fire") {
+		t.Errorf("log = %q, want the original error", logged.String())
+	}
+}
+
+func TestFailedMutationsChangeNothing(t *testing.T) {
+	quotes := store.NewMemory(Quote{ID: 1, Quote: "One"})
+	events := newEventBus()
+	updated, stop := events.Subscribe(topicQuoteUpdated)
+	defer stop()
+	deleted, stop := events.Subscribe(topicQuoteDeleted)
+	defer stop()
+	schema, _ := newSchema(quotes, store.NewSequence(1), events)
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `mutation {
+		update(id: 2, quote: "Two") { id }
+		delete(id: 2) { id }
+	}`})
+	if len(result.Errors) != 2 {
+		t.Fatalf("errors = %v", result.Errors)
+	}
+	if data := result.Data.(map[string]interface{}); data["update"] != nil || data["delete"] != nil {
+		t.Errorf("data = %v, want null fields", data)
+	}
+	if len(updated) != 0 || len(deleted) != 0 {
+		t.Error("failed mutations published events")
+	}
+	if list, _ := quotes.List(context.Background(), store.Query{}); len(list) != 1 {
+		t.Errorf("quotes = %v", list)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "sync"
+
+// Topics of the event bus, named after the Subscription fields they feed.
+const (
+	topicQuoteCreated = "quoteCreated"
+	topicQuoteUpdated = "quoteUpdated"
+	topicQuoteDeleted = "quoteDeleted"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// it is dropped.
+const subscriberBuffer = 16
+
+// eventBus delivers changed quotes to subscribers in the same process.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Quote]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: map[string]map[chan Quote]struct{}{}}
+}
+
+// Publish sends q to every subscriber of topic. It never blocks: a
+// subscriber that has fallen too far behind is dropped, which closes its
+// channel.
+func (b *eventBus) Publish(topic string, q Quote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[topic] {
+		select {
+		case ch <- q:
+		default:
+			delete(b.subs[topic], ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the quotes published on topic, and
+// a function that unsubscribes and closes the channel.
+func (b *eventBus) Subscribe(topic string) (<-chan Quote, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Quote, subscriberBuffer)
+	if b.subs[topic] == nil {
+		b.subs[topic] = map[chan Quote]struct{}{}
+	}
+	b.subs[topic][ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[topic][ch]; ok {
+			delete(b.subs[topic], ch)
+			close(ch)
+		}
+	}
+}
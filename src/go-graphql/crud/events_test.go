@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestEventBus(t *testing.T) {
+	bus := newEventBus()
+	created, stopCreated := bus.Subscribe(topicQuoteCreated)
+	deleted, _ := bus.Subscribe(topicQuoteDeleted)
+
+	bus.Publish(topicQuoteCreated, Quote{ID: 1})
+	if q := <-created; q.ID != 1 {
+		t.Errorf("got quote %d, want 1", q.ID)
+	}
+	if len(deleted) != 0 {
+		t.Error("event went to another topic")
+	}
+
+	stopCreated()
+	if _, ok := <-created; ok {
+		t.Error("channel open after unsubscribing")
+	}
+	stopCreated()
+	bus.Publish(topicQuoteCreated, Quote{ID: 2})
+}
+
+func TestEventBusDropsSlowSubscribers(t *testing.T) {
+	bus := newEventBus()
+	events, stop := bus.Subscribe(topicQuoteUpdated)
+	defer stop()
+
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		bus.Publish(topicQuoteUpdated, Quote{ID: id})
+	}
+	received := 0
+	for range events {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d events before being dropped, want %d", received, subscriberBuffer)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// orderNames are the QuoteOrder values.
+var orderNames = map[store.Order]string{
+	store.OrderIDAsc:    "ID_ASC",
+	store.OrderIDDesc:   "ID_DESC",
+	store.OrderDateAsc:  "DATE_ASC",
+	store.OrderDateDesc: "DATE_DESC",
+}
+
+var quoteOrderEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name:        "QuoteOrder",
+	Description: "Order of quotes in a list; quotes of the same date are ordered by id",
+	Values: graphql.EnumValueConfigMap{
+		orderNames[store.OrderIDAsc]:    &graphql.EnumValueConfig{Value: store.OrderIDAsc, Description: "Lowest id first"},
+		orderNames[store.OrderIDDesc]:   &graphql.EnumValueConfig{Value: store.OrderIDDesc, Description: "Highest id first"},
+		orderNames[store.OrderDateAsc]:  &graphql.EnumValueConfig{Value: store.OrderDateAsc, Description: "Oldest first"},
+		orderNames[store.OrderDateDesc]: &graphql.EnumValueConfig{Value: store.OrderDateDesc, Description: "Newest first"},
+	},
+})
+
+var quoteFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "QuoteFilter",
+	Description: "Selects quotes matching every field given",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"author": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "Exact author",
+		},
+		"anyTags": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Quotes with at least one of these tags",
+		},
+		"allTags": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Quotes with all of these tags",
+		},
+		"since": &graphql.InputObjectFieldConfig{
+			Type:        graphql.DateTime,
+			Description: "Quotes dated at or after this time",
+		},
+		"until": &graphql.InputObjectFieldConfig{
+			Type:        graphql.DateTime,
+			Description: "Quotes dated before this time",
+		},
+		"text": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "Quotes containing this text, ignoring case",
+		},
+	},
+})
+
+// listArgs are the arguments of fields listing quotes.
+var listArgs = graphql.FieldConfigArgument{
+	"filter":  &graphql.ArgumentConfig{Type: quoteFilterInput},
+	"orderBy": &graphql.ArgumentConfig{Type: quoteOrderEnum, DefaultValue: store.OrderIDAsc},
+}
+
+// mergeArgs combines sets of arguments into one.
+func mergeArgs(sets ...graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{}
+	for _, set := range sets {
+		for name, arg := range set {
+			args[name] = arg
+		}
+	}
+	return args
+}
+
+// queryFrom builds the store query described by the listArgs.
+func queryFrom(args map[string]interface{}) store.Query {
+	query := store.Query{}
+	query.Order, _ = args["orderBy"].(store.Order)
+	filter, _ := args["filter"].(map[string]interface{})
+	query.Author, _ = filter["author"].(string)
+	query.AnyTags = stringList(filter["anyTags"])
+	query.AllTags = stringList(filter["allTags"])
+	if since, ok := filter["since"].(time.Time); ok {
+		query.Since = &since
+	}
+	if until, ok := filter["until"].(time.Time); ok {
+		query.Until = &until
+	}
+	query.Text, _ = filter["text"].(string)
+	return query
+}
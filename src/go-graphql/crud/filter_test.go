@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestListFilterAndOrder(t *testing.T) {
+	day := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	schema, err := newSchema(store.NewMemory(
+		Quote{ID: 1, Quote: "Old news", Author: "Ann", Tags: []string{"x"}, Date: day},
+		Quote{ID: 2, Quote: "Fresh news", Author: "Ann", Tags: []string{"x", "y"}, Date: day.AddDate(0, 0, 2)},
+		Quote{ID: 3, Quote: "Fresh news", Author: "Bob", Tags: []string{"x", "y"}, Date: day.AddDate(0, 0, 1)},
+		Quote{ID: 4, Quote: "No news", Author: "Ann", Date: day.AddDate(0, 0, 3)},
+	), store.NewSequence(4), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		ids   []interface{}
+	}{
+		{`{ list { id } }`, []interface{}{"1", "2", "3", "4"}},
+		{`{ list(orderBy: DATE_DESC) { id } }`, []interface{}{"4", "2", "3", "1"}},
+		{`{ list(filter: {author: "Ann"}, orderBy: ID_DESC) { id } }`, []interface{}{"4", "2", "1"}},
+		{`{ list(filter: {anyTags: ["y", "z"]}) { id } }`, []interface{}{"2", "3"}},
+		{`{ list(filter: {allTags: ["x", "y"], author: "Bob"}) { id } }`, []interface{}{"3"}},
+		{`{ list(filter: {since: "2021-03-02T00:00:00Z", until: "2021-03-04T00:00:00Z"}, orderBy: DATE_ASC) { id } }`, []interface{}{"3", "2"}},
+		{`{ list(filter: {text: "FRESH"}) { id } }`, []interface{}{"2", "3"}},
+		{`{ quotes(filter: {text: "news"}, orderBy: DATE_DESC, first: 2) { edges { node { id } } } }`, []interface{}{"4", "2"}},
+	} {
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: tt.query})
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: %v", tt.query, result.Errors)
+		}
+		data := result.Data.(map[string]interface{})
+		var ids []interface{}
+		if list, ok := data["list"].([]interface{}); ok {
+			for _, q := range list {
+				ids = append(ids, q.(map[string]interface{})["id"])
+			}
+		} else {
+			for _, edge := range data["quotes"].(map[string]interface{})["edges"].([]interface{}) {
+				ids = append(ids, edge.(map[string]interface{})["node"].(map[string]interface{})["id"])
+			}
+		}
+		if !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("%s: ids = %v, want %v", tt.query, ids, tt.ids)
+		}
+	}
+}
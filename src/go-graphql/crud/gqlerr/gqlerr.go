@@ -0,0 +1,182 @@
+// Package gqlerr gives resolver errors a code, reported as extensions.code
+// in the GraphQL response, so that clients can tell a missing quote from
+// bad input or a fault in the server without matching on messages.
+package gqlerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// Code classifies an error for the client.
+type Code string
+
+const (
+	// NotFound means the quote, author or tag asked for does not exist.
+	NotFound Code = "NOT_FOUND"
+	// BadUserInput means an argument was invalid; sending it again will
+	// fail again.
+	BadUserInput Code = "BAD_USER_INPUT"
+	// Internal means the server failed; the request itself may be fine.
+	Internal Code = "INTERNAL"
+)
+
+// Error is an error with a code. graphql-go reports the code through
+// Extensions.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// Error returns the message sent to the client. Internal errors only say
+// so: their messages may hold file paths or database details, and the
+// schema's Extension logs them on the server instead.
+func (e *Error) Error() string {
+	if e.Code == Internal {
+		return "internal error"
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e *Error) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": string(e.Code)}
+}
+
+// New returns an error with code and a message formatted as by fmt.Errorf.
+func New(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// CodeOf returns the code of err, Internal if it has none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Internal
+}
+
+// Store errors the client can fix by changing the arguments. ErrExists is
+// not among them: clients never choose quote IDs.
+var badUserInput = []error{
+	store.ErrInvalidID,
+	store.ErrAuthorExists,
+	store.ErrAuthorName,
+	store.ErrTagExists,
+	store.ErrTagName,
+}
+
+// Classify gives err a code: NotFound or BadUserInput for the store errors
+// that mean so, Internal for anything else without a code. It returns nil
+// for nil and errors that have a code already as they are.
+func Classify(err error) error {
+	var e *Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrAuthorNotFound) || errors.Is(err, store.ErrTagNotFound) {
+		return &Error{Code: NotFound, Err: err}
+	}
+	for _, target := range badUserInput {
+		if errors.Is(err, target) {
+			return &Error{Code: BadUserInput, Err: err}
+		}
+	}
+	return &Error{Code: Internal, Err: err}
+}
+
+// Resolve wraps fn so that every error it returns is classified, including
+// errors of the thunk it may return for batched loading. The codes of
+// thunk errors only reach the response if the schema has Extension.
+func Resolve(fn graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := fn(p)
+		if err != nil {
+			return nil, Classify(err)
+		}
+		thunk, ok := result.(func() (interface{}, error))
+		if !ok {
+			return result, nil
+		}
+		return func() (interface{}, error) {
+			result, err := thunk()
+			return result, Classify(err)
+		}, nil
+	}
+}
+
+// Extension restores the extensions of errors returned by thunks and logs
+// internal errors with their original message. Add it to the schema's
+// Extensions.
+//
+// graphql-go formats an error returned by a thunk before locating it in
+// the query, so the located error wraps a FormattedError rather than the
+// error itself and loses its Extensions. Once execution finishes, the
+// extension looks through the wrapping for the original error.
+type Extension struct{}
+
+var _ graphql.Extension = Extension{}
+
+func (Extension) Name() string { return "gqlerr" }
+
+func (Extension) Init(ctx context.Context, p *graphql.Params) context.Context { return ctx }
+
+func (Extension) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+func (Extension) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+func (Extension) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(result *graphql.Result) {
+		for i, e := range result.Errors {
+			original := unwrapFormatted(e)
+			var coded *Error
+			if errors.As(original, &coded) && coded.Code == Internal {
+				log.Printf("internal error at %v: %v", e.Path, coded.Err)
+			}
+			if e.Extensions != nil {
+				continue
+			}
+			var extended gqlerrors.ExtendedError
+			if errors.As(original, &extended) {
+				result.Errors[i].Extensions = extended.Extensions()
+			}
+		}
+	}
+}
+
+func (Extension) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	return ctx, func(interface{}, error) {}
+}
+
+func (Extension) HasResult() bool { return false }
+
+func (Extension) GetResult(ctx context.Context) interface{} { return nil }
+
+// unwrapFormatted returns the error graphql-go wrapped in err, which
+// neither FormattedError nor gqlerrors.Error expose through Unwrap.
+func unwrapFormatted(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package gqlerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-graphdl/crud/store"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		code Code
+	}{
+		{store.ErrNotFound, NotFound},
+		{store.ErrAuthorNotFound, NotFound},
+		{fmt.Errorf("renaming: %w", store.ErrTagNotFound), NotFound},
+		{store.ErrInvalidID, BadUserInput},
+		{store.ErrAuthorName, BadUserInput},
+		{store.ErrTagExists, BadUserInput},
+		{store.ErrExists, Internal},
+		{errors.New("disk on fire"), Internal},
+		{New(BadUserInput, "bad %s", "input"), BadUserInput},
+	} {
+		err := Classify(tt.err)
+		if code := CodeOf(err); code != tt.code {
+			t.Errorf("code of %v = %s, want %s", tt.err, code, tt.code)
+		}
+		message := tt.err.Error()
+		if tt.code == Internal {
+			message = "internal error"
+		}
+		if err.Error() != message || !errors.Is(err, tt.err) {
+			t.Errorf("Classify(%v) = %v, want it wrapped", tt.err, err)
+		}
+		if got := err.(*Error).Extensions()["code"]; got != string(tt.code) {
+			t.Errorf("extensions.code of %v = %v", tt.err, got)
+		}
+	}
+	if Classify(nil) != nil {
+		t.Error("Classify(nil) != nil")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Media types from the GraphQL-over-HTTP spec:
+// https://graphql.github.io/graphql-over-http/draft/
+const (
+	mediaTypeJSON                  = "application/json"
+	mediaTypeGraphQL               = "application/graphql"
+	mediaTypeGraphQLResponse       = "application/graphql-response+json"
+	maxRequestBytes          int64 = 1 << 20
+)
+
+// graphQLRequest is the body of a POST request, or the query string of a
+// GET request.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// graphQLHandler serves schema over HTTP. Queries may be sent with GET or
+// POST, mutations only with POST.
+//
+// Responses use application/graphql-response+json when the client accepts
+// it, in which case requests that fail to parse or validate get 400. With
+// plain application/json every well-formed request gets 200, errors or not.
+func graphQLHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responseType, ok := negotiate(r.Header.Get("Accept"))
+		if !ok {
+			http.Error(w, "Accept must allow "+mediaTypeGraphQLResponse+" or "+mediaTypeJSON, http.StatusNotAcceptable)
+			return
+		}
+
+		var req graphQLRequest
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			req, err = readGetRequest(r)
+		case http.MethodPost:
+			req, err = readPostRequest(w, r)
+			var tooLarge *http.MaxBytesError
+			switch {
+			case err == errUnsupportedMediaType:
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+				return
+			case errors.As(err, &tooLarge):
+				writeResult(w, responseType, http.StatusRequestEntityTooLarge,
+					errorResult(fmt.Errorf("request body must be at most %d bytes", tooLarge.Limit)))
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			writeResult(w, responseType, http.StatusBadRequest, errorResult(err))
+			return
+		}
+		if req.Query == "" {
+			writeResult(w, responseType, http.StatusBadRequest, errorResult(fmt.Errorf("missing query")))
+			return
+		}
+
+		doc, op, errs := prepare(schema, req)
+		if errs != nil {
+			writeResult(w, responseType, requestErrorStatus(responseType), &graphql.Result{Errors: errs})
+			return
+		}
+		if op.Operation == ast.OperationTypeSubscription {
+			writeResult(w, responseType, requestErrorStatus(responseType),
+				errorResult(fmt.Errorf("subscriptions are served over WebSocket with the %s protocol", subprotocolGraphQLWS)))
+			return
+		}
+		if r.Method == http.MethodGet && op.Operation != ast.OperationTypeQuery {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, op.Operation+" operations must be sent with POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       r.Context(),
+		})
+		writeResult(w, responseType, http.StatusOK, result)
+	})
+}
+
+var errUnsupportedMediaType = fmt.Errorf("Content-Type must be %s or %s", mediaTypeJSON, mediaTypeGraphQL)
+
+func readGetRequest(r *http.Request) (graphQLRequest, error) {
+	q := r.URL.Query()
+	req := graphQLRequest{
+		Query:         q.Get("query"),
+		OperationName: q.Get("operationName"),
+	}
+	if v := q.Get("variables"); v != "" {
+		if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+			return req, fmt.Errorf("variables must be a JSON object: %v", err)
+		}
+	}
+	return req, nil
+}
+
+func readPostRequest(w http.ResponseWriter, r *http.Request) (graphQLRequest, error) {
+	var req graphQLRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		return req, err
+	}
+
+	switch mediaType {
+	case mediaTypeJSON:
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, fmt.Errorf("invalid JSON body: %v", err)
+		}
+	case mediaTypeGraphQL:
+		// The body is the document; anything else comes from the URL.
+		req, err = readGetRequest(r)
+		req.Query = string(body)
+	default:
+		return req, errUnsupportedMediaType
+	}
+	return req, err
+}
+
+// prepare parses and validates the request here rather than in graphql.Do,
+// so that callers can tell request errors from execution errors and see
+// which kind of operation is being run.
+func prepare(schema graphql.Schema, req graphQLRequest) (*ast.Document, *ast.OperationDefinition, []gqlerrors.FormattedError) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return nil, nil, gqlerrors.FormatErrors(err)
+	}
+	if v := graphql.ValidateDocument(&schema, doc, nil); !v.IsValid {
+		return nil, nil, v.Errors
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return nil, nil, errorResult(err).Errors
+	}
+	return doc, op, nil
+}
+
+// selectOperation finds the operation to run, as graphql.Execute would.
+func selectOperation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "" && found != nil:
+			return nil, fmt.Errorf("operationName is required for documents with several operations")
+		case name == "" || (op.Name != nil && op.Name.Value == name):
+			found = op
+		}
+	}
+	if found == nil {
+		if name != "" {
+			return nil, fmt.Errorf("unknown operation %q", name)
+		}
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return found, nil
+}
+
+// negotiate picks the response media type from an Accept header. A missing
+// header or wildcard gets the legacy application/json.
+func negotiate(accept string) (string, bool) {
+	if accept == "" {
+		return mediaTypeJSON, true
+	}
+	acceptsJSON := false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case mediaTypeGraphQLResponse:
+			return mediaTypeGraphQLResponse, true
+		case mediaTypeJSON, "application/*", "*/*":
+			acceptsJSON = true
+		}
+	}
+	return mediaTypeJSON, acceptsJSON
+}
+
+func requestErrorStatus(responseType string) int {
+	if responseType == mediaTypeGraphQLResponse {
+		return http.StatusBadRequest
+	}
+	return http.StatusOK
+}
+
+func errorResult(err error) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}}
+}
+
+// writeResult does not log result.Errors: most are the client's doing, and
+// gqlerr.Extension already logs the internal ones.
+func writeResult(w http.ResponseWriter, responseType string, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", responseType+"; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
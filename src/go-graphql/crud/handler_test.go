@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+)
+
+type response struct {
+	Data   map[string]interface{}   `json:"data"`
+	Errors []map[string]interface{} `json:"errors"`
+}
+
+func serve(t *testing.T, req *http.Request) (*httptest.ResponseRecorder, response) {
+	t.Helper()
+	rec, resp, _ := serveStore(t, req)
+	return rec, resp
+}
+
+// serveStore runs req against a memory store holding one quote, returned
+// so that tests can check the effect of mutations.
+func serveStore(t *testing.T, req *http.Request) (*httptest.ResponseRecorder, response, store.QuoteStore) {
+	t.Helper()
+	quotes := store.NewMemory(Quote{ID: 1, Quote: "Hello world", Author: "John Will", Date: time.Now()})
+	schema, err := newSchema(quotes, store.NewSequence(1), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	loaderMiddleware(quotes, graphQLHandler(schema)).ServeHTTP(rec, req)
+	var resp response
+	if strings.Contains(rec.Header().Get("Content-Type"), "json") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding %q: %v", rec.Body, err)
+		}
+	}
+	return rec, resp, quotes
+}
+
+func post(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func get(params url.Values) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+}
+
+func TestPostJSON(t *testing.T) {
+	req := post("application/json; charset=utf-8", `{
+		"query": "query One($id: ID!) { quote(id: $id) { author { name } } } query All { list { id } }",
+		"variables": {"id": 1},
+		"operationName": "One"
+	}`)
+	rec, resp := serve(t, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	quote, _ := resp.Data["quote"].(map[string]interface{})
+	author, _ := quote["author"].(map[string]interface{})
+	if author["name"] != "John Will" || len(resp.Errors) != 0 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestPostGraphQL(t *testing.T) {
+	req := post("application/graphql", `{ list { id quote } }`)
+	req.Header.Set("Accept", "application/graphql-response+json")
+	rec, resp := serve(t, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/graphql-response+json; charset=utf-8" {
+		t.Fatalf("got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if list, _ := resp.Data["list"].([]interface{}); len(list) != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestGet(t *testing.T) {
+	rec, resp := serve(t, get(url.Values{
+		"query":     {"query($id: ID!) { quote(id: $id) { quote } }"},
+		"variables": {`{"id": 1}`},
+	}))
+	quote, _ := resp.Data["quote"].(map[string]interface{})
+	if rec.Code != http.StatusOK || quote["quote"] != "Hello world" {
+		t.Errorf("got %d %+v", rec.Code, resp)
+	}
+}
+
+func TestGetRejectsMutations(t *testing.T) {
+	rec, _, quotes := serveStore(t, get(url.Values{
+		"query": {`mutation { delete(id: 1) { id } }`},
+	}))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "POST" {
+		t.Errorf("got %d, Allow: %q", rec.Code, rec.Header().Get("Allow"))
+	}
+	if list, _ := quotes.List(context.Background(), store.Query{}); len(list) != 1 {
+		t.Error("mutation ran over GET")
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		accept string
+		status int
+	}{
+		{"bad JSON", func() *http.Request { return post("application/json", `{"query":`) }, "", http.StatusBadRequest},
+		{"missing query", func() *http.Request { return post("application/json", `{}`) }, "", http.StatusBadRequest},
+		{"bad variables", func() *http.Request { return get(url.Values{"query": {"{list{id}}"}, "variables": {"[1]"}}) }, "", http.StatusBadRequest},
+		{"unknown operation", func() *http.Request {
+			return post("application/json", `{"query":"query A{list{id}}","operationName":"B"}`)
+		}, "application/graphql-response+json", http.StatusBadRequest},
+		{"syntax error, graphql-response", func() *http.Request { return post("application/graphql", `{ list {`) }, "application/graphql-response+json", http.StatusBadRequest},
+		{"syntax error, json", func() *http.Request { return post("application/graphql", `{ list {`) }, "application/json", http.StatusOK},
+		{"invalid field, graphql-response", func() *http.Request { return post("application/graphql", `{ nope }`) }, "application/graphql-response+json", http.StatusBadRequest},
+		{"invalid field, json", func() *http.Request { return post("application/graphql", `{ nope }`) }, "*/*", http.StatusOK},
+		{"body too large", func() *http.Request {
+			return post("application/json", `{"query":"`+strings.Repeat(" ", int(maxRequestBytes))+`{list{id}}"}`)
+		}, "", http.StatusRequestEntityTooLarge},
+		{"unsupported content type", func() *http.Request { return post("text/plain", `{ list { id } }`) }, "", http.StatusUnsupportedMediaType},
+		{"not acceptable", func() *http.Request { return get(url.Values{"query": {"{list{id}}"}}) }, "text/html", http.StatusNotAcceptable},
+		{"wrong method", func() *http.Request { return httptest.NewRequest(http.MethodPut, "/graphql", nil) }, "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req()
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec, resp := serve(t, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if strings.Contains(rec.Header().Get("Content-Type"), "json") && len(resp.Errors) == 0 {
+				t.Errorf("no errors in %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestClientErrorsAreNotLogged(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	serve(t, post("application/graphql", `{ nope }`))
+	serve(t, post("application/json", `{"query":"{ quote(id: 9) { id } }"}`))
+
+	if logged.Len() != 0 {
+		t.Errorf("log = %q, want nothing", logged.String())
+	}
+}
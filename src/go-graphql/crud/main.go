@@ -1,54 +1,41 @@
 package main
 
 import (
-	"io/ioutil"
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"math/rand"
+	"log"
 	"net/http"
-	"reflect"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
+	"go-graphdl/crud/gqlerr"
+	"go-graphdl/crud/store"
+
 	"github.com/graphql-go/graphql"
 )
+
 // Quote contains information about one quote
-type Quote struct {
-	ID     int64     `json:"id"`
-	Quote  string    `json:"quote"`
-	Author string    `json:"author,omitempty"`
-	Tags   []string  `json:"tags,omitempty"`
-	Date   time.Time `json:"date"`
-}
+type Quote = store.Quote
 
 // https://www.sohamkamani.com/golang/2018-07-19-golang-omitempty/
 
-/*var quotes = []Quote{
-	{
-		ID:     1,
-		Quote:  "Hello world",
-		Author: "John Will",
-		Tags: []string{
-			"love",
-			"hate",
-		},
-		Date: time.Now(), //change to default date
-	},
-}*/
-
-var quotes []Quote
-
 var quoteType = graphql.NewObject(
 	graphql.ObjectConfig{
 		Name: "Quote",
 		Fields: graphql.Fields{
 			"id": &graphql.Field{
-				Type: graphql.Int,
+				Type: graphql.NewNonNull(graphql.ID),
 			},
 			"quote": &graphql.Field{
 				Type: graphql.String,
 			},
 			"author": &graphql.Field{
-				Type: graphql.String,
+				Type:    authorType,
+				Resolve: gqlerr.Resolve(resolveQuoteAuthor),
 			},
 			"tags": &graphql.Field{
 				Type: graphql.NewList(graphql.String),
@@ -60,223 +47,415 @@ var quoteType = graphql.NewObject(
 	},
 )
 
-var queryType = graphql.NewObject(
-	graphql.ObjectConfig{
-		Name: "Query",
-		Fields: graphql.Fields{
+// newSchema builds the schema, resolving every field against quotes. New
+// quotes get their IDs from ids, and changes to quotes are published on
+// events.
+func newSchema(quotes store.QuoteStore, ids store.IDGenerator, events *eventBus) (graphql.Schema, error) {
+	queryType := graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
 
-			/* Get (read) single quote by id
-			   http://localhost:8080/quote?query={quote(id:1){quote,author,tags,date}}
-			*/
-			"quote": &graphql.Field{
-				Type:        quoteType,
-				Description: "Get quote by id",
-				Args: graphql.FieldConfigArgument{
-					"id": &graphql.ArgumentConfig{
-						Type: graphql.Int,
+				/* Get (read) single quote by id
+				   http://localhost:8080/quote?query={quote(id:1){quote,author{name},tags,date}}
+				*/
+				"quote": &graphql.Field{
+					Type:        quoteType,
+					Description: "Get quote by id",
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{
+							Type: graphql.NewNonNull(graphql.ID),
+						},
 					},
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						id, err := parseID(p.Args["id"])
+						if err != nil {
+							return nil, err
+						}
+						return quotes.Get(p.Context, id)
+					}),
 				},
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					id, ok := p.Args["id"].(int)
-					if ok {
-						// find quote
-						for _, quote := range quotes {
-							if int(quote.ID) == id {
-								return quote, nil
-							}
+
+				/* Get (read) a page of quotes
+				   http://localhost:8080/graphql?query={quotes(first:10){edges{cursor,node{id,quote}},pageInfo{hasNextPage,endCursor}}}
+				*/
+				"quotes": &graphql.Field{
+					Type:        quoteConnectionType,
+					Description: "Page through the quotes",
+					Args:        mergeArgs(listArgs, connectionArgs),
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						query := queryFrom(p.Args)
+						list, err := quotes.List(p.Context, query)
+						if err != nil {
+							return nil, err
 						}
-					}
-					return nil, nil
+						return paginate(list, query.Order, pageArgsFrom(p.Args))
+					}),
 				},
-			},
 
-			/* Get (read) quote list
-			   http://localhost:8080/quotet?query={list{id,quote,author,tags,date}}
-			*/
-			"list": &graphql.Field{
-				Type:        graphql.NewList(quoteType),
-				Description: "Get all the quotes",
-				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-					return quotes, nil
+				/* Get (read) single author by id
+				   http://localhost:8080/quote?query={author(id:1){name,bio,quotes(first:5){edges{node{quote}}}}}
+				*/
+				"author": &graphql.Field{
+					Type:        authorType,
+					Description: "Get author by id",
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{
+							Type: graphql.NewNonNull(graphql.ID),
+						},
+					},
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						id, err := parseID(p.Args["id"])
+						if err != nil {
+							return nil, err
+						}
+						return quotes.GetAuthor(p.Context, id)
+					}),
 				},
-			},
-		},
-	},
-)
 
-var mutationType = graphql.NewObject(graphql.ObjectConfig{
-	Name: "Mutation",
-	Fields: graphql.Fields{
-
-		// Create a new quote
-		"create": &graphql.Field{
-			Type:        quoteType,
-			Description: "Create a new quote",
-			Args: graphql.FieldConfigArgument{
-				"quote": &graphql.ArgumentConfig{
-					Type: graphql.NewNonNull(graphql.String),
+				/* Get (read) author list
+				   http://localhost:8080/quote?query={authors{id,name,bio}}
+				*/
+				"authors": &graphql.Field{
+					Type:        graphql.NewList(authorType),
+					Description: "Get all the authors",
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						return quotes.ListAuthors(p.Context)
+					}),
 				},
-				"author": &graphql.ArgumentConfig{
-					Type: graphql.String,
+
+				/* Get (read) the tags in use
+				   http://localhost:8080/quote?query={tags{name,count}}
+				*/
+				"tags": &graphql.Field{
+					Type:        graphql.NewList(tagType),
+					Description: "Get every tag in use, by name",
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						return quotes.Tags(p.Context)
+					}),
 				},
-				"tags": &graphql.ArgumentConfig{
-					Type: graphql.NewList(graphql.String),
+
+				/* Get (read) quote list
+				   http://localhost:8080/quote?query={list{id,quote,author{name},tags,date}}
+				   http://localhost:8080/quote?query={list(filter:{author:"John Hill"},orderBy:DATE_DESC){id,quote}}
+				*/
+				"list": &graphql.Field{
+					Type:        graphql.NewList(quoteType),
+					Description: "Get the quotes, all of them unless filtered",
+					Args:        listArgs,
+					Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+						return quotes.List(p.Context, queryFrom(p.Args))
+					}),
 				},
 			},
-			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				//overflow.com/questions/44027826/convert-interface-to-string-in-golang
-				tags := p.Args["tags"].([]interface{})
-				fmt.Println("Type of tags: ",reflect.TypeOf(tags))
-				stringTags := make([]string, len(tags))
-				for i, v := range tags {
-					stringTags[i] = fmt.Sprint(v)
-				}
-				//https://flaviocopes.com/go-random/
-				rand.Seed(time.Now().UnixNano())
-				quote := Quote{
-					ID:     int64(rand.Intn(100000)), // generate random ID
-					Quote:  p.Args["quote"].(string),
-					Author: p.Args["author"].(string),
-					Tags:   stringTags,
-					Date:   time.Now(),
-				}
-				quotes = append(quotes, quote)
-				return quote, nil
-			},
 		},
+	)
+
+	mutationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
 
-		"update": &graphql.Field{
-			Type: quoteType,
-			Description: "Update quote by id",
-			Args: graphql.FieldConfigArgument{
-				"id": &graphql.ArgumentConfig{
-					Type: graphql.NewNonNull(graphql.Int),
+			// Create a new quote
+			"create": &graphql.Field{
+				Type:        quoteType,
+				Description: "Create a new quote",
+				Args: graphql.FieldConfigArgument{
+					"quote": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"author": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+					"tags": &graphql.ArgumentConfig{
+						Type: graphql.NewList(graphql.String),
+					},
 				},
-				"quote": &graphql.ArgumentConfig{
-					Type: graphql.String,
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					author, _ := p.Args["author"].(string)
+					quote := Quote{
+						Quote:  p.Args["quote"].(string),
+						Author: author,
+						Tags:   stringList(p.Args["tags"]),
+						Date:   time.Now(),
+					}
+					// The store has the final say on uniqueness: an ID may
+					// be taken by a quote added behind the generator's back,
+					// for example by editing data.json.
+					for attempt := 0; ; attempt++ {
+						quote.ID = ids.NextID()
+						created, err := quotes.Create(p.Context, quote)
+						if err == nil {
+							events.Publish(topicQuoteCreated, created)
+							return created, nil
+						}
+						if err != store.ErrExists || attempt == maxCreateAttempts {
+							return nil, err
+						}
+					}
+				}),
+			},
+
+			"update": &graphql.Field{
+				Type:        quoteType,
+				Description: "Update quote by id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					"quote": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+					"author": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+					"tags": &graphql.ArgumentConfig{
+						Type: graphql.NewList(graphql.String),
+					},
+					"date": &graphql.ArgumentConfig{
+						Type: graphql.DateTime,
+					},
 				},
-				"author": &graphql.ArgumentConfig{
-					Type: graphql.String,
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					var patch store.QuotePatch
+					if quote, ok := p.Args["quote"].(string); ok {
+						patch.Quote = &quote
+					}
+					if author, ok := p.Args["author"].(string); ok {
+						patch.Author = &author
+					}
+					if tags, ok := p.Args["tags"]; ok {
+						patch.Tags, patch.SetTags = stringList(tags), true
+					}
+					if date, ok := p.Args["date"].(time.Time); ok {
+						patch.Date = &date
+					}
+					quote, err := quotes.Update(p.Context, id, patch)
+					if err != nil {
+						return nil, err
+					}
+					events.Publish(topicQuoteUpdated, quote)
+					return quote, nil
+				}),
+			},
+
+			"delete": &graphql.Field{
+				Type:        quoteType,
+				Description: "Delete quote by id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
 				},
-				"tags": &graphql.ArgumentConfig{
-					Type: graphql.NewList(graphql.String),
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					quote, err := quotes.Delete(p.Context, id)
+					if err != nil {
+						return nil, err
+					}
+					events.Publish(topicQuoteDeleted, quote)
+					return quote, nil
+				}),
+			},
+
+			// Update an author; renaming them renames the author of all
+			// their quotes
+			"updateAuthor": &graphql.Field{
+				Type:        authorType,
+				Description: "Update author by id",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.ID),
+					},
+					"name": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+					"bio": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
 				},
-				"date": &graphql.ArgumentConfig{
-					Type: graphql.DateTime,
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"])
+					if err != nil {
+						return nil, err
+					}
+					var patch store.AuthorPatch
+					if name, ok := p.Args["name"].(string); ok {
+						patch.Name = &name
+					}
+					if bio, ok := p.Args["bio"].(string); ok {
+						patch.Bio = &bio
+					}
+					return quotes.UpdateAuthor(p.Context, id, patch)
+				}),
+			},
+
+			// Rename a tag on every quote carrying it
+			"renameTag": &graphql.Field{
+				Type:        tagType,
+				Description: "Rename a tag to one not in use yet",
+				Args: graphql.FieldConfigArgument{
+					"from": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+					"to": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
 				},
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					return quotes.RenameTag(p.Context, p.Args["from"].(string), p.Args["to"].(string))
+				}),
 			},
-			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				id, _ := p.Args["id"].(int)
-				quote, quoteOk := p.Args["quote"].(string)
-				author, authorOk := p.Args["author"].(string)
-				tags, tagsOk := p.Args["tags"].([] string)
-				date, dateOk := p.Args["date"].(string)
-				quoteRecord := Quote{}
-				dateFormatted := convertStrToTime(date)
-				for i, q := range quotes {
-					if int64(id) == q.ID {
-						if quoteOk {
-							quotes[i].Quote = quote
-						}
-						if authorOk {
-							quotes[i].Author = author
-						}
-						if tagsOk {
-							quotes[i].Tags = tags
-						}
-						if dateOk {
-							quotes[i].Date = dateFormatted
-						}
-						quoteRecord = quotes[i]
-						break
-					}
-				}
-				return quoteRecord, nil
+
+			// Replace several tags with one on every quote carrying them
+			"mergeTags": &graphql.Field{
+				Type:        tagType,
+				Description: "Merge tags into one, which may be in use already",
+				Args: graphql.FieldConfigArgument{
+					"from": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					},
+					"into": &graphql.ArgumentConfig{
+						Type: graphql.NewNonNull(graphql.String),
+					},
+				},
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					return quotes.MergeTags(p.Context, stringList(p.Args["from"]), p.Args["into"].(string))
+				}),
 			},
 		},
+	})
 
-		"delete": &graphql.Field{
-			Type: quoteType,
-			Description: "Delete quote by id",
-			Args: graphql.FieldConfigArgument{
-				"id": &graphql.ArgumentConfig{
-					Type: graphql.NewNonNull(graphql.Int),
-				},
+	// Subscription fields resolve to the quote of the event being sent;
+	// see graphQLWSHandler.
+	subscriptionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Subscription",
+		Fields: graphql.Fields{
+			topicQuoteCreated: &graphql.Field{
+				Type:        graphql.NewNonNull(quoteType),
+				Description: "A quote was created",
 			},
-			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-				id, _ := p.Args["id"].(int)
-				quote := Quote{}
-				for i, q := range quotes {
-					if int64(id) == q.ID {
-						quote = quotes[i]
-						// Remove from quotes list
-						quotes = append(quotes[:i], quotes[i+1:]...)
-					}
-				}
-				return quote, nil
+			topicQuoteUpdated: &graphql.Field{
+				Type:        graphql.NewNonNull(quoteType),
+				Description: "A quote was updated",
+			},
+			topicQuoteDeleted: &graphql.Field{
+				Type:        graphql.NewNonNull(quoteType),
+				Description: "A quote was deleted",
 			},
 		},
-	},
-})
+	})
 
-var schema, _ = graphql.NewSchema(
-	graphql.SchemaConfig{
-		Query:    queryType,
-		Mutation: mutationType,
-	},
-)
+	return graphql.NewSchema(
+		graphql.SchemaConfig{
+			Query:        queryType,
+			Mutation:     mutationType,
+			Subscription: subscriptionType,
+			Extensions:   []graphql.Extension{gqlerr.Extension{}},
+		},
+	)
+}
 
-func executeQuery(query string, schema graphql.Schema) *graphql.Result {
-	fmt.Printf(query)
-	result := graphql.Do(graphql.Params{
-		Schema:        schema,
-		RequestString: query,
-	})
-	if len(result.Errors) > 0 {
-		fmt.Printf("errors: %v", result.Errors)
+// maxCreateAttempts bounds how many IDs create tries before giving up.
+const maxCreateAttempts = 10
+
+// parseID converts an ID argument, which is always a string.
+func parseID(arg interface{}) (int64, error) {
+	s, _ := arg.(string)
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, gqlerr.New(gqlerr.BadUserInput, "invalid id %q", s)
 	}
-	return result
+	return id, nil
 }
 
-func convertStrToTime(timeStr string) time.Time {
-	layout := "2006-01-02T15:04:05.000Z"
-	t, err := time.Parse(layout, timeStr)
-	if err !=  nil {
-		fmt.Println(err)
+// stringList converts a [String] argument, see
+// https://stackoverflow.com/questions/44027826/convert-interface-to-string-in-golang
+func stringList(arg interface{}) []string {
+	values, _ := arg.([]interface{})
+	if values == nil {
+		return nil
+	}
+	list := make([]string, len(values))
+	for i, v := range values {
+		list[i] = fmt.Sprint(v)
 	}
-	return t
+	return list
 }
 
-func main() {
-	_ = importJSONgo("data.json")
-	http.HandleFunc("/quote", func(w http.ResponseWriter, r *http.Request) {
-		result := executeQuery(r.URL.Query().Get("query"), schema)
-		json.NewEncoder(w).Encode(result)
-	})
+// newIDGenerator returns the generator named by kind.
+func newIDGenerator(kind string, node int64, quotes store.QuoteStore) (store.IDGenerator, error) {
+	switch kind {
+	case "sequence":
+		return store.SeedSequence(context.Background(), quotes)
+	case "snowflake":
+		return store.NewSnowflake(node, nil)
+	default:
+		return nil, fmt.Errorf("unknown id generator %q, want sequence or snowflake", kind)
+	}
+}
 
-	fmt.Println("Server is up and running on port 8080")
-	http.ListenAndServe(":8080", nil)
+// openStore opens the backend named by kind.
+func openStore(kind, dataFile, dbFile string) (store.QuoteStore, error) {
+	switch kind {
+	case "memory":
+		return store.NewMemory(), nil
+	case "json":
+		return store.OpenJSONFile(dataFile)
+	case "sqlite":
+		return store.OpenSQLite(dbFile)
+	default:
+		return nil, fmt.Errorf("unknown store %q, want memory, json or sqlite", kind)
+	}
 }
 
-// Helper function to import JSON from file to map
-func importJSONgo(fileName string) (isOK bool) {
-	isOK = true
-	var f map[string]Quote
-	content, err := ioutil.ReadFile(fileName)
+func main() {
+	kind := flag.String("store", "json", "quote backend: memory, json or sqlite")
+	dataFile := flag.String("data", "data.json", "file used by the json store")
+	dbFile := flag.String("db", "quotes.db", "database used by the sqlite store")
+	idKind := flag.String("ids", "sequence", "id generator: sequence or snowflake")
+	node := flag.Int64("node", 0, "node number of this server for snowflake ids, 0-1023")
+	flag.Parse()
+
+	quotes, err := openStore(*kind, *dataFile, *dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ids, err := newIDGenerator(*idKind, *node, quotes)
 	if err != nil {
-		fmt.Println("Error on reading file: ", err)
-		isOK = false
-		
+		log.Fatal(err)
 	}
-	err = json.Unmarshal(content, &f)
+	events := newEventBus()
+	schema, err := newSchema(quotes, ids, events)
 	if err != nil {
-		isOK = false
-		fmt.Println("Error on parsing: ", err)
+		log.Fatal(err)
 	}
 
-	// Convert interface map to list of quote
-	for _, value := range f {
-		quotes = append(quotes, value)
+	handler := webSocketOr(graphQLWSHandler(schema, events, quotes),
+		loaderMiddleware(quotes, graphQLHandler(schema)))
+	http.Handle("/graphql", handler)
+	http.Handle("/quote", handler)
+
+	// Stop cleanly on Ctrl-C so that the store can save pending changes.
+	srv := &http.Server{Addr: ":8080"}
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+		srv.Shutdown(context.Background())
+	}()
+
+	fmt.Println("Server is up and running on port 8080")
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Print(err)
+	}
+	if err := quotes.Close(); err != nil {
+		log.Fatal(err)
 	}
-	return
 }
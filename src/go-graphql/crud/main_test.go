@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestMutationsUseStore(t *testing.T) {
+	quotes := store.NewMemory()
+	schema, err := newSchema(quotes, store.NewSequence(0), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(query string) map[string]interface{} {
+		t.Helper()
+		result := graphql.Do(graphql.Params{Schema: schema, RequestString: query})
+		if len(result.Errors) > 0 {
+			t.Fatalf("%s: %v", query, result.Errors)
+		}
+		return result.Data.(map[string]interface{})
+	}
+	ctx := context.Background()
+
+	created := do(`mutation { create(quote: "Hi", tags: ["a", "b"]) { id } }`)["create"].(map[string]interface{})
+	if created["id"] != "1" {
+		t.Errorf("created id = %v, want \"1\"", created["id"])
+	}
+	id := int64(1)
+	if q, err := quotes.Get(ctx, id); err != nil || q.Quote != "Hi" || len(q.Tags) != 2 {
+		t.Fatalf("stored quote = %+v, %v", q, err)
+	}
+
+	do(`mutation { update(id: ` + itoa(id) + `, author: "Ann", tags: ["c"], date: "2020-01-02T03:04:05Z") { id } }`)
+	q, _ := quotes.Get(ctx, id)
+	if q.Author != "Ann" || len(q.Tags) != 1 || q.Tags[0] != "c" || !q.Date.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("updated quote = %+v", q)
+	}
+
+	do(`mutation { delete(id: ` + itoa(id) + `) { id } }`)
+	if list, _ := quotes.List(ctx, store.Query{}); len(list) != 0 {
+		t.Errorf("quotes after delete = %v", list)
+	}
+}
+
+func itoa(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func TestCreateSkipsTakenIDs(t *testing.T) {
+	// Quote 2 was added without the generator knowing.
+	quotes := store.NewMemory(Quote{ID: 2, Quote: "Taken"})
+	schema, _ := newSchema(quotes, store.NewSequence(1), newEventBus())
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `mutation { create(quote: "New") { id } }`})
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	created := result.Data.(map[string]interface{})["create"].(map[string]interface{})
+	if created["id"] != "3" {
+		t.Errorf("created id = %v, want \"3\"", created["id"])
+	}
+}
@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrAuthorNotFound = errors.New("author not found")
+	ErrAuthorExists   = errors.New("author name already in use")
+	ErrAuthorName     = errors.New("author name must not be empty")
+)
+
+// Author is the author of quotes. Quotes refer to their author by name,
+// which is unique; the store adds an author the first time a quote names
+// them.
+type Author struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio,omitempty"`
+}
+
+// AuthorPatch holds the fields to change in an author; nil fields are left
+// alone.
+type AuthorPatch struct {
+	Name *string
+	Bio  *string
+}
+
+// AuthorStore is the author half of a QuoteStore. Authors are never
+// deleted, so they keep their bio while they have no quotes.
+type AuthorStore interface {
+	GetAuthor(ctx context.Context, id int64) (Author, error)
+	// AuthorsByName returns the authors with the given names, leaving out
+	// names without one, in no particular order.
+	AuthorsByName(ctx context.Context, names []string) ([]Author, error)
+	// ListAuthors returns every author by ID.
+	ListAuthors(ctx context.Context) ([]Author, error)
+	// UpdateAuthor changes an author. Renaming them renames the author of
+	// all their quotes at once, and fails with ErrAuthorExists if the name
+	// is taken.
+	UpdateAuthor(ctx context.Context, id int64, patch AuthorPatch) (Author, error)
+}
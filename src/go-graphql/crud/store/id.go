@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// IDGenerator hands out IDs for new quotes. Stores still reject duplicates,
+// so a generator only has to make them unlikely.
+type IDGenerator interface {
+	NextID() int64
+}
+
+// Sequence counts up from the largest ID it was seeded with.
+type Sequence struct {
+	mu   sync.Mutex
+	last int64
+}
+
+func NewSequence(last int64) *Sequence {
+	return &Sequence{last: last}
+}
+
+// SeedSequence returns a sequence continuing after the largest ID in s.
+func SeedSequence(ctx context.Context, s QuoteStore) (*Sequence, error) {
+	quotes, err := s.List(ctx, Query{})
+	if err != nil {
+		return nil, err
+	}
+	var last int64
+	for _, q := range quotes {
+		if q.ID > last {
+			last = q.ID
+		}
+	}
+	return NewSequence(last), nil
+}
+
+func (s *Sequence) NextID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last++
+	return s.last
+}
+
+// Snowflake generates IDs that stay unique across up to 1024 servers
+// without coordination: 41 bits of milliseconds since 2020, 10 bits of
+// node number and a 12-bit counter for IDs within the same millisecond.
+type Snowflake struct {
+	node int64
+	now  func() time.Time
+
+	mu    sync.Mutex
+	last  int64
+	count int64
+}
+
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	snowflakeNodeBits  = 10
+	snowflakeCountBits = 12
+)
+
+// NewSnowflake returns a generator for node, which must be below 1024. A
+// nil now uses the wall clock.
+func NewSnowflake(node int64, now func() time.Time) (*Snowflake, error) {
+	if node < 0 || node >= 1<<snowflakeNodeBits {
+		return nil, errors.New("snowflake node must be between 0 and 1023")
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &Snowflake{node: node, now: now}, nil
+}
+
+func (s *Snowflake) NextID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < s.last {
+		// The clock went backwards; keep counting from the last time seen.
+		ms = s.last
+	}
+	if ms == s.last {
+		s.count++
+		if s.count == 1<<snowflakeCountBits {
+			// Out of IDs for this millisecond: borrow the next one.
+			ms++
+			s.count = 0
+		}
+	} else {
+		s.count = 0
+	}
+	s.last = ms
+	return ms<<(snowflakeNodeBits+snowflakeCountBits) | s.node<<snowflakeCountBits | s.count
+}
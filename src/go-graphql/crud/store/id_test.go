@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSeedSequence(t *testing.T) {
+	s := NewMemory(Quote{ID: 7}, Quote{ID: 42}, Quote{ID: 3})
+	seq, err := SeedSequence(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := seq.NextID(), seq.NextID(); a != 43 || b != 44 {
+		t.Errorf("NextID = %d, %d, want 43, 44", a, b)
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	now := snowflakeEpoch.Add(time.Hour)
+	clock := func() time.Time { return now }
+	a, _ := NewSnowflake(1, clock)
+	b, _ := NewSnowflake(2, clock)
+
+	// More IDs than fit in one millisecond, from two nodes at once.
+	seen := map[int64]bool{}
+	var last int64
+	for i := 0; i < 5000; i++ {
+		id := a.NextID()
+		if id <= last {
+			t.Fatalf("ID %d after %d is not increasing", id, last)
+		}
+		last = id
+		for _, id := range []int64{id, b.NextID()} {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	// A clock going backwards does not reuse IDs.
+	now = now.Add(-time.Minute)
+	if id := a.NextID(); id <= last {
+		t.Errorf("ID %d after the clock went back is not above %d", id, last)
+	}
+
+	if _, err := NewSnowflake(1024, nil); err == nil {
+		t.Error("NewSnowflake accepted node 1024")
+	}
+}
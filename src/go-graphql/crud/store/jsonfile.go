@@ -0,0 +1,293 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDebounce is how long JSONFile waits for further changes before
+// writing the file.
+const DefaultDebounce = 200 * time.Millisecond
+
+// JSONFile keeps quotes in memory and writes them back to a JSON file,
+// such as data.json, after every change. The file holds an indented object
+// keyed by quote ID, so it can still be edited by hand:
+//
+//	{"1": {"id": 1, "quote": "...", ...}}
+//
+// Authors go to a second file next to it, data.authors.json for
+// data.json, once there are any. It is written first, so that it is never
+// behind the quotes.
+//
+// Writes go to a temporary file that is renamed over the original, so a
+// crash never leaves a half-written file. A burst of changes is written
+// once, after the debounce delay; Flush and Close write straight away and
+// report write errors, which mutations only log. Failed writes are retried
+// in the background, backing off up to maxRetryDelay.
+type JSONFile struct {
+	*Memory
+	path        string
+	authorsPath string
+	debounce    time.Duration
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	retry  time.Duration
+	closed bool
+	dirty  bool
+	err    error
+	writes int
+}
+
+// maxRetryDelay caps the wait between retries of a failed write.
+const maxRetryDelay = time.Minute
+
+// JSONFileOption configures a JSONFile opened with OpenJSONFile.
+type JSONFileOption func(*JSONFile)
+
+// WithDebounce sets how long to wait for more changes before writing. Zero
+// writes on every change.
+func WithDebounce(d time.Duration) JSONFileOption {
+	return func(f *JSONFile) {
+		f.debounce = d
+	}
+}
+
+// OpenJSONFile loads the quotes in path and their authors. Missing files
+// are treated as empty and created on the first change.
+func OpenJSONFile(path string, opts ...JSONFileOption) (*JSONFile, error) {
+	byID := map[string]Quote{}
+	if err := readJSON(path, &byID); err != nil {
+		return nil, err
+	}
+	authorsPath := strings.TrimSuffix(path, ".json") + ".authors.json"
+	var authors []Author
+	if err := readJSON(authorsPath, &authors); err != nil {
+		return nil, err
+	}
+
+	quotes := make([]Quote, 0, len(byID))
+	for _, q := range byID {
+		quotes = append(quotes, q)
+	}
+	f := &JSONFile{
+		Memory:      newMemory(authors, quotes),
+		path:        path,
+		authorsPath: authorsPath,
+		debounce:    DefaultDebounce,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+func (f *JSONFile) Create(ctx context.Context, q Quote) (Quote, error) {
+	q, err := f.Memory.Create(ctx, q)
+	if err != nil {
+		return q, err
+	}
+	f.changed()
+	return q, nil
+}
+
+func (f *JSONFile) Update(ctx context.Context, id int64, patch QuotePatch) (Quote, error) {
+	q, err := f.Memory.Update(ctx, id, patch)
+	if err != nil {
+		return q, err
+	}
+	f.changed()
+	return q, nil
+}
+
+func (f *JSONFile) Delete(ctx context.Context, id int64) (Quote, error) {
+	q, err := f.Memory.Delete(ctx, id)
+	if err != nil {
+		return q, err
+	}
+	f.changed()
+	return q, nil
+}
+
+func (f *JSONFile) UpdateAuthor(ctx context.Context, id int64, patch AuthorPatch) (Author, error) {
+	a, err := f.Memory.UpdateAuthor(ctx, id, patch)
+	if err != nil {
+		return a, err
+	}
+	f.changed()
+	return a, nil
+}
+
+func (f *JSONFile) RenameTag(ctx context.Context, from, to string) (Tag, error) {
+	tag, err := f.Memory.RenameTag(ctx, from, to)
+	if err != nil {
+		return tag, err
+	}
+	f.changed()
+	return tag, nil
+}
+
+func (f *JSONFile) MergeTags(ctx context.Context, from []string, into string) (Tag, error) {
+	tag, err := f.Memory.MergeTags(ctx, from, into)
+	if err != nil {
+		return tag, err
+	}
+	f.changed()
+	return tag, nil
+}
+
+// readJSON decodes the file at path into v, leaving v alone if the file is
+// missing or empty.
+func readJSON(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return json.Unmarshal(content, v)
+}
+
+// Flush writes any pending changes now. It reports the error of the last
+// write if that failed and nothing has been written since.
+func (f *JSONFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.flushLocked()
+	// A retry still due after a failure stays scheduled.
+	if err == nil && f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	return err
+}
+
+// Close writes any pending changes and stops retrying failed writes.
+func (f *JSONFile) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	return f.Flush()
+}
+
+// changed records a change and writes it, or schedules the write. The
+// change is already made in memory, so a failed write is not reported to
+// the caller, whose retry would make the change twice. It is logged
+// instead, retried later, and returned by Flush and Close.
+func (f *JSONFile) changed() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dirty = true
+	if f.debounce <= 0 {
+		f.save()
+		return
+	}
+	f.schedule(f.debounce)
+}
+
+// schedule arranges for pending changes to be saved after delay, unless a
+// save is already due. It must be called with f.mu held.
+func (f *JSONFile) schedule(delay time.Duration) {
+	if f.timer != nil || f.closed {
+		return
+	}
+	f.timer = time.AfterFunc(delay, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.timer = nil
+		f.save()
+	})
+}
+
+// save writes pending changes. A failure is logged and the write retried,
+// waiting twice as long each time. It must be called with f.mu held.
+func (f *JSONFile) save() {
+	err := f.flushLocked()
+	if err == nil {
+		f.retry = 0
+		return
+	}
+	log.Printf("saving %s: %v", f.path, err)
+
+	if f.retry == 0 {
+		f.retry = f.debounce
+		if f.retry <= 0 {
+			f.retry = DefaultDebounce
+		}
+	} else if f.retry *= 2; f.retry > maxRetryDelay {
+		f.retry = maxRetryDelay
+	}
+	f.schedule(f.retry)
+}
+
+// flushLocked must be called with f.mu held.
+func (f *JSONFile) flushLocked() error {
+	if !f.dirty {
+		return f.err
+	}
+	f.err = f.write()
+	if f.err == nil {
+		f.dirty = false
+		f.writes++
+	}
+	return f.err
+}
+
+// write replaces the files.
+func (f *JSONFile) write() error {
+	quotes, authors := f.Memory.snapshot()
+	if len(authors) > 0 {
+		sort.Slice(authors, func(i, j int) bool {
+			return authors[i].ID < authors[j].ID
+		})
+		if err := writeJSON(f.authorsPath, authors); err != nil {
+			return err
+		}
+	}
+	byID := map[string]Quote{}
+	for id, q := range quotes {
+		byID[strconv.FormatInt(id, 10)] = q
+	}
+	return writeJSON(f.path, byID)
+}
+
+// writeJSON replaces the file at path with v, atomically.
+func writeJSON(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Make sure the content is on disk before it replaces the old file.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJSONFileDebouncesWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	f, err := OpenJSONFile(path, WithDebounce(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for id := int64(1); id <= 10; id++ {
+		f.Create(ctx, Quote{ID: id, Quote: "Burst"})
+	}
+	if f.writes != 0 {
+		t.Fatalf("%d writes before the debounce delay", f.writes)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if f.writes != 1 {
+		t.Errorf("burst of 10 changes took %d writes, want 1", f.writes)
+	}
+
+	// Nothing but the data file is left behind.
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "data.json" {
+		t.Errorf("directory holds %v", entries)
+	}
+
+	reopened, err := OpenJSONFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quotes, _ := reopened.List(ctx, Query{}); len(quotes) != 10 {
+		t.Errorf("reopened file has %d quotes, want 10", len(quotes))
+	}
+}
+
+func TestJSONFileWritesAfterDelay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	f, _ := OpenJSONFile(path, WithDebounce(10*time.Millisecond))
+	f.Create(context.Background(), Quote{ID: 1, Quote: "Later"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if content, err := os.ReadFile(path); err == nil && len(content) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file never written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJSONFileReportsWriteErrors(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing-dir")
+	f, _ := OpenJSONFile(filepath.Join(dir, "data.json"), WithDebounce(0))
+	ctx := context.Background()
+
+	// The quote is stored in memory, so Create must not fail: a retry
+	// would add it twice.
+	if _, err := f.Create(ctx, Quote{ID: 1, Quote: "One"}); err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+	if err := f.Flush(); err == nil {
+		t.Error("Flush succeeded although the file cannot be written")
+	}
+	if quotes, _ := f.List(ctx, Query{}); len(quotes) != 1 {
+		t.Errorf("quotes = %v, want one", quotes)
+	}
+
+	// Once the file can be written, the next change saves everything.
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Create(ctx, Quote{ID: 2, Quote: "Two"}); err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	reopened, _ := OpenJSONFile(filepath.Join(dir, "data.json"))
+	if quotes, _ := reopened.List(ctx, Query{}); len(quotes) != 2 {
+		t.Errorf("reopened file has %d quotes, want 2", len(quotes))
+	}
+}
+
+func TestJSONFileRetriesFailedWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing-dir")
+	path := filepath.Join(dir, "data.json")
+	f, _ := OpenJSONFile(path, WithDebounce(time.Millisecond))
+	defer f.Close()
+
+	if _, err := f.Create(context.Background(), Quote{ID: 1, Quote: "One"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// No further change is made: the failed write is retried on its own.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if content, err := os.ReadFile(path); err == nil && len(content) > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("failed write was never retried")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
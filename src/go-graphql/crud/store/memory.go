@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory keeps quotes in a map. The zero value is not usable; use
+// NewMemory.
+type Memory struct {
+	mu      sync.RWMutex
+	quotes  map[int64]Quote
+	authors map[int64]Author
+	// authorIDs finds authors by name.
+	authorIDs  map[string]int64
+	lastAuthor int64
+}
+
+// NewMemory returns a store holding quotes, with authors numbered in the
+// order of the IDs of their first quotes.
+func NewMemory(quotes ...Quote) *Memory {
+	return newMemory(nil, quotes)
+}
+
+func newMemory(authors []Author, quotes []Quote) *Memory {
+	m := &Memory{
+		quotes:    map[int64]Quote{},
+		authors:   map[int64]Author{},
+		authorIDs: map[string]int64{},
+	}
+	for _, a := range authors {
+		m.authors[a.ID] = a
+		m.authorIDs[a.Name] = a.ID
+		if a.ID > m.lastAuthor {
+			m.lastAuthor = a.ID
+		}
+	}
+	quotes = append([]Quote(nil), quotes...)
+	sortQuotes(quotes, OrderIDAsc)
+	for _, q := range quotes {
+		m.quotes[q.ID] = clone(q)
+		m.addAuthorLocked(q.Author)
+	}
+	return m
+}
+
+// addAuthorLocked adds an author called name unless there is one already.
+// It must be called with m.mu held for writing.
+func (m *Memory) addAuthorLocked(name string) {
+	if _, ok := m.authorIDs[name]; ok || name == "" {
+		return
+	}
+	m.lastAuthor++
+	m.authors[m.lastAuthor] = Author{ID: m.lastAuthor, Name: name}
+	m.authorIDs[name] = m.lastAuthor
+}
+
+func (m *Memory) Get(ctx context.Context, id int64) (Quote, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	q, ok := m.quotes[id]
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	return clone(q), nil
+}
+
+func (m *Memory) List(ctx context.Context, query Query) ([]Quote, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	quotes := []Quote{}
+	for _, q := range m.quotes {
+		if query.Match(q) {
+			quotes = append(quotes, clone(q))
+		}
+	}
+	sortQuotes(quotes, query.Order)
+	return quotes, nil
+}
+
+func (m *Memory) Create(ctx context.Context, q Quote) (Quote, error) {
+	if q.ID <= 0 {
+		return Quote{}, ErrInvalidID
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.quotes[q.ID]; ok {
+		return Quote{}, ErrExists
+	}
+	q = clone(q)
+	m.quotes[q.ID] = q
+	m.addAuthorLocked(q.Author)
+	return clone(q), nil
+}
+
+func (m *Memory) Update(ctx context.Context, id int64, patch QuotePatch) (Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.quotes[id]
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	patch.apply(&q)
+	m.quotes[id] = q
+	m.addAuthorLocked(q.Author)
+	return clone(q), nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id int64) (Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.quotes[id]
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	delete(m.quotes, id)
+	return q, nil
+}
+
+func (m *Memory) GetAuthor(ctx context.Context, id int64) (Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	a, ok := m.authors[id]
+	if !ok {
+		return Author{}, ErrAuthorNotFound
+	}
+	return a, nil
+}
+
+func (m *Memory) AuthorsByName(ctx context.Context, names []string) ([]Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	authors := []Author{}
+	seen := map[int64]bool{}
+	for _, name := range names {
+		if id, ok := m.authorIDs[name]; ok && !seen[id] {
+			seen[id] = true
+			authors = append(authors, m.authors[id])
+		}
+	}
+	return authors, nil
+}
+
+func (m *Memory) ListAuthors(ctx context.Context) ([]Author, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	authors := make([]Author, 0, len(m.authors))
+	for _, a := range m.authors {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].ID < authors[j].ID
+	})
+	return authors, nil
+}
+
+func (m *Memory) UpdateAuthor(ctx context.Context, id int64, patch AuthorPatch) (Author, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.authors[id]
+	if !ok {
+		return Author{}, ErrAuthorNotFound
+	}
+	if patch.Name != nil && *patch.Name == "" {
+		return Author{}, ErrAuthorName
+	}
+	if patch.Name != nil && *patch.Name != a.Name {
+		if _, taken := m.authorIDs[*patch.Name]; taken {
+			return Author{}, ErrAuthorExists
+		}
+		for qid, q := range m.quotes {
+			if q.Author == a.Name {
+				q.Author = *patch.Name
+				m.quotes[qid] = q
+			}
+		}
+		delete(m.authorIDs, a.Name)
+		a.Name = *patch.Name
+		m.authorIDs[a.Name] = id
+	}
+	if patch.Bio != nil {
+		a.Bio = *patch.Bio
+	}
+	m.authors[id] = a
+	return a, nil
+}
+
+func (m *Memory) Tags(ctx context.Context) ([]Tag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tags := []Tag{}
+	for name, count := range m.tagCountsLocked() {
+		tags = append(tags, Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (m *Memory) RenameTag(ctx context.Context, from, to string) (Tag, error) {
+	return m.retag([]string{from}, to, true)
+}
+
+func (m *Memory) MergeTags(ctx context.Context, from []string, into string) (Tag, error) {
+	return m.retag(from, into, false)
+}
+
+func (m *Memory) retag(from []string, into string, rename bool) (Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, err := checkRetag(m.tagCountsLocked(), from, into, rename)
+	if err != nil {
+		return Tag{}, err
+	}
+	for id, q := range m.quotes {
+		if tags, changed := retag(q.Tags, set, into); changed {
+			q.Tags = tags
+			m.quotes[id] = q
+		}
+	}
+	return Tag{Name: into, Count: m.tagCountsLocked()[into]}, nil
+}
+
+// tagCountsLocked counts the quotes carrying each tag. It must be called
+// with m.mu held.
+func (m *Memory) tagCountsLocked() map[string]int {
+	counts := map[string]int{}
+	for _, q := range m.quotes {
+		seen := map[string]bool{}
+		for _, tag := range q.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+	}
+	return counts
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+// snapshot returns every quote keyed by ID, and every author.
+func (m *Memory) snapshot() (map[int64]Quote, []Author) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	quotes := make(map[int64]Quote, len(m.quotes))
+	for id, q := range m.quotes {
+		quotes[id] = clone(q)
+	}
+	authors := make([]Author, 0, len(m.authors))
+	for _, a := range m.authors {
+		authors = append(authors, a)
+	}
+	return quotes, authors
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"strings"
+	"time"
+)
+
+// Order is the order List returns quotes in. Orders by date break ties by
+// ID, so every order is total; the descending orders are the exact
+// reverse of the ascending ones.
+type Order int
+
+const (
+	OrderIDAsc Order = iota
+	OrderIDDesc
+	OrderDateAsc
+	OrderDateDesc
+)
+
+// Less reports whether a comes before b.
+func (o Order) Less(a, b Quote) bool {
+	switch o {
+	case OrderIDDesc:
+		return b.ID < a.ID
+	case OrderDateAsc:
+		return dateLess(a, b)
+	case OrderDateDesc:
+		return dateLess(b, a)
+	default:
+		return a.ID < b.ID
+	}
+}
+
+func dateLess(a, b Quote) bool {
+	if !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	return a.ID < b.ID
+}
+
+// Query selects and orders the quotes returned by List. The zero Query
+// returns every quote by ID.
+type Query struct {
+	// Author matches the author exactly.
+	Author string
+	// AnyTags matches quotes with at least one of the tags, AllTags quotes
+	// with every one of them.
+	AnyTags []string
+	AllTags []string
+	// Since and Until bound the date; Since is inclusive, Until exclusive.
+	Since *time.Time
+	Until *time.Time
+	// Text matches quotes containing it, ignoring case.
+	Text  string
+	Order Order
+}
+
+// Match reports whether q is selected by the query. Backends that cannot
+// filter natively use it; the others must agree with it.
+func (qy Query) Match(q Quote) bool {
+	if qy.Author != "" && q.Author != qy.Author {
+		return false
+	}
+	if len(qy.AnyTags) > 0 && !hasAny(q.Tags, qy.AnyTags) {
+		return false
+	}
+	for _, tag := range qy.AllTags {
+		if !hasAny(q.Tags, []string{tag}) {
+			return false
+		}
+	}
+	if qy.Since != nil && q.Date.Before(*qy.Since) {
+		return false
+	}
+	if qy.Until != nil && !q.Date.Before(*qy.Until) {
+		return false
+	}
+	if qy.Text != "" && !strings.Contains(strings.ToLower(q.Quote), strings.ToLower(qy.Text)) {
+		return false
+	}
+	return true
+}
+
+func hasAny(tags, want []string) bool {
+	for _, tag := range tags {
+		for _, w := range want {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
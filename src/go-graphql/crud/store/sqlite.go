@@ -0,0 +1,467 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite" // pure-Go driver, no cgo needed
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS quotes (
+	id     INTEGER PRIMARY KEY,
+	quote  TEXT NOT NULL,
+	author TEXT NOT NULL DEFAULT '',
+	tags   TEXT NOT NULL DEFAULT '[]',
+	date   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS authors (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	bio  TEXT NOT NULL DEFAULT ''
+);
+-- Add the authors of quotes saved before there was an authors table.
+INSERT OR IGNORE INTO authors (name)
+	SELECT author FROM quotes WHERE author != '' GROUP BY author ORDER BY min(id)`
+
+// sqliteTime writes dates in UTC at a fixed width, so that comparing and
+// sorting the text agrees with comparing the times.
+const sqliteTime = "2006-01-02T15:04:05.000000000Z07:00"
+
+func init() {
+	// contains_fold(s, substr) is strings.Contains ignoring case; SQLite's
+	// own lower() only knows ASCII.
+	sqlite.MustRegisterDeterministicScalarFunction("contains_fold", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, substr := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+			return strings.Contains(strings.ToLower(s), strings.ToLower(substr)), nil
+		})
+}
+
+// SQLite keeps quotes in a SQLite database. Tags are stored as a JSON
+// array and dates as RFC 3339 text; List filters and sorts in SQL.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the database at path; ":memory:" gives a
+// private in-memory database.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time, and every connection to
+	// ":memory:" would see a different database.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &SQLite{db: db}
+	if err := s.migrateDates(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrateDates rewrites dates saved before they had a fixed width.
+func (s *SQLite) migrateDates() error {
+	rows, err := s.db.Query(`SELECT id, date FROM quotes WHERE date NOT LIKE '____-__-__T__:__:__._________Z'`)
+	if err != nil {
+		return err
+	}
+	dates := map[int64]time.Time{}
+	for rows.Next() {
+		var id int64
+		var date string
+		if err := rows.Scan(&id, &date); err != nil {
+			rows.Close()
+			return err
+		}
+		if dates[id], err = time.Parse(time.RFC3339Nano, date); err != nil {
+			rows.Close()
+			return fmt.Errorf("quote %d: %v", id, err)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, date := range dates {
+		if _, err := s.db.Exec(`UPDATE quotes SET date = ? WHERE id = ?`, date.UTC().Format(sqliteTime), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuote(row scanner) (Quote, error) {
+	var q Quote
+	var tags, date string
+	if err := row.Scan(&q.ID, &q.Quote, &q.Author, &tags, &date); err != nil {
+		if err == sql.ErrNoRows {
+			return q, ErrNotFound
+		}
+		return q, err
+	}
+	if err := json.Unmarshal([]byte(tags), &q.Tags); err != nil {
+		return q, err
+	}
+	if len(q.Tags) == 0 {
+		q.Tags = nil
+	}
+	var err error
+	q.Date, err = time.Parse(time.RFC3339Nano, date)
+	return q, err
+}
+
+func (s *SQLite) Get(ctx context.Context, id int64) (Quote, error) {
+	return scanQuote(s.db.QueryRowContext(ctx,
+		`SELECT id, quote, author, tags, date FROM quotes WHERE id = ?`, id))
+}
+
+var sqliteOrder = map[Order]string{
+	OrderIDAsc:    "id",
+	OrderIDDesc:   "id DESC",
+	OrderDateAsc:  "date, id",
+	OrderDateDesc: "date DESC, id DESC",
+}
+
+func (s *SQLite) List(ctx context.Context, query Query) ([]Quote, error) {
+	var where []string
+	var args []interface{}
+	if query.Author != "" {
+		where = append(where, "author = ?")
+		args = append(args, query.Author)
+	}
+	if len(query.AnyTags) > 0 {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(tags) WHERE value IN ("+placeholders(len(query.AnyTags))+"))")
+		for _, tag := range query.AnyTags {
+			args = append(args, tag)
+		}
+	}
+	for _, tag := range query.AllTags {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(tags) WHERE value = ?)")
+		args = append(args, tag)
+	}
+	if query.Since != nil {
+		where = append(where, "date >= ?")
+		args = append(args, query.Since.UTC().Format(sqliteTime))
+	}
+	if query.Until != nil {
+		where = append(where, "date < ?")
+		args = append(args, query.Until.UTC().Format(sqliteTime))
+	}
+	if query.Text != "" {
+		where = append(where, "contains_fold(quote, ?)")
+		args = append(args, query.Text)
+	}
+
+	stmt := `SELECT id, quote, author, tags, date FROM quotes`
+	if len(where) > 0 {
+		stmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	order, ok := sqliteOrder[query.Order]
+	if !ok {
+		order = sqliteOrder[OrderIDAsc]
+	}
+	stmt += " ORDER BY " + order
+
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotes := []Quote{}
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, rows.Err()
+}
+
+func (s *SQLite) Create(ctx context.Context, q Quote) (Quote, error) {
+	if q.ID <= 0 {
+		return Quote{}, ErrInvalidID
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM quotes WHERE id = ?)`, q.ID).Scan(&exists); err != nil {
+		return Quote{}, err
+	}
+	if exists {
+		return Quote{}, ErrExists
+	}
+	if err := s.put(ctx, tx, q); err != nil {
+		return Quote{}, err
+	}
+	return clone(q), tx.Commit()
+}
+
+func (s *SQLite) Update(ctx context.Context, id int64, patch QuotePatch) (Quote, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer tx.Rollback()
+
+	q, err := scanQuote(tx.QueryRowContext(ctx,
+		`SELECT id, quote, author, tags, date FROM quotes WHERE id = ?`, id))
+	if err != nil {
+		return Quote{}, err
+	}
+	patch.apply(&q)
+	if err := s.put(ctx, tx, q); err != nil {
+		return Quote{}, err
+	}
+	return q, tx.Commit()
+}
+
+func (s *SQLite) Delete(ctx context.Context, id int64) (Quote, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer tx.Rollback()
+
+	q, err := scanQuote(tx.QueryRowContext(ctx,
+		`SELECT id, quote, author, tags, date FROM quotes WHERE id = ?`, id))
+	if err != nil {
+		return Quote{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM quotes WHERE id = ?`, id); err != nil {
+		return Quote{}, err
+	}
+	return q, tx.Commit()
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func scanAuthor(row scanner) (Author, error) {
+	var a Author
+	err := row.Scan(&a.ID, &a.Name, &a.Bio)
+	if err == sql.ErrNoRows {
+		return a, ErrAuthorNotFound
+	}
+	return a, err
+}
+
+func (s *SQLite) GetAuthor(ctx context.Context, id int64) (Author, error) {
+	return scanAuthor(s.db.QueryRowContext(ctx, `SELECT id, name, bio FROM authors WHERE id = ?`, id))
+}
+
+func (s *SQLite) AuthorsByName(ctx context.Context, names []string) ([]Author, error) {
+	if len(names) == 0 {
+		return []Author{}, nil
+	}
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	return s.authors(ctx, `SELECT id, name, bio FROM authors WHERE name IN (`+placeholders(len(names))+`)`, args...)
+}
+
+func (s *SQLite) ListAuthors(ctx context.Context) ([]Author, error) {
+	return s.authors(ctx, `SELECT id, name, bio FROM authors ORDER BY id`)
+}
+
+func (s *SQLite) authors(ctx context.Context, query string, args ...interface{}) ([]Author, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []Author{}
+	for rows.Next() {
+		a, err := scanAuthor(rows)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, a)
+	}
+	return authors, rows.Err()
+}
+
+func (s *SQLite) UpdateAuthor(ctx context.Context, id int64, patch AuthorPatch) (Author, error) {
+	if patch.Name != nil && *patch.Name == "" {
+		return Author{}, ErrAuthorName
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Author{}, err
+	}
+	defer tx.Rollback()
+
+	a, err := scanAuthor(tx.QueryRowContext(ctx, `SELECT id, name, bio FROM authors WHERE id = ?`, id))
+	if err != nil {
+		return Author{}, err
+	}
+	if patch.Name != nil && *patch.Name != a.Name {
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM authors WHERE name = ?)`, *patch.Name).Scan(&taken); err != nil {
+			return Author{}, err
+		}
+		if taken {
+			return Author{}, ErrAuthorExists
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE quotes SET author = ? WHERE author = ?`, *patch.Name, a.Name); err != nil {
+			return Author{}, err
+		}
+		a.Name = *patch.Name
+	}
+	if patch.Bio != nil {
+		a.Bio = *patch.Bio
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE authors SET name = ?, bio = ? WHERE id = ?`, a.Name, a.Bio, id); err != nil {
+		return Author{}, err
+	}
+	return a, tx.Commit()
+}
+
+// sqliteTagCounts counts the quotes carrying each tag.
+const sqliteTagCounts = `SELECT tag.value, count(DISTINCT quotes.id) FROM quotes, json_each(quotes.tags) AS tag
+	GROUP BY tag.value ORDER BY tag.value`
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func tagCounts(ctx context.Context, db querier) ([]Tag, error) {
+	rows, err := db.QueryContext(ctx, sqliteTagCounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (s *SQLite) Tags(ctx context.Context) ([]Tag, error) {
+	return tagCounts(ctx, s.db)
+}
+
+func (s *SQLite) RenameTag(ctx context.Context, from, to string) (Tag, error) {
+	return s.retag(ctx, []string{from}, to, true)
+}
+
+func (s *SQLite) MergeTags(ctx context.Context, from []string, into string) (Tag, error) {
+	return s.retag(ctx, from, into, false)
+}
+
+func (s *SQLite) retag(ctx context.Context, from []string, into string, rename bool) (Tag, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Tag{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := tagCounts(ctx, tx)
+	if err != nil {
+		return Tag{}, err
+	}
+	inUse := map[string]int{}
+	for _, tag := range before {
+		inUse[tag.Name] = tag.Count
+	}
+	set, err := checkRetag(inUse, from, into, rename)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	// Collect the changes before making them: the connection is busy
+	// until the rows are closed.
+	rows, err := tx.QueryContext(ctx, `SELECT id, quote, author, tags, date FROM quotes`)
+	if err != nil {
+		return Tag{}, err
+	}
+	var changed []Quote
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			rows.Close()
+			return Tag{}, err
+		}
+		if tags, ok := retag(q.Tags, set, into); ok {
+			q.Tags = tags
+			changed = append(changed, q)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Tag{}, err
+	}
+	for _, q := range changed {
+		if err := s.put(ctx, tx, q); err != nil {
+			return Tag{}, err
+		}
+	}
+
+	tag := Tag{Name: into}
+	err = tx.QueryRowContext(ctx,
+		`SELECT count(*) FROM quotes WHERE EXISTS (SELECT 1 FROM json_each(tags) WHERE value = ?)`, into).Scan(&tag.Count)
+	if err != nil {
+		return Tag{}, err
+	}
+	return tag, tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// put inserts or replaces q, adding its author if they are new.
+func (s *SQLite) put(ctx context.Context, db execer, q Quote) error {
+	if q.Author != "" {
+		if _, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO authors (name) VALUES (?)`, q.Author); err != nil {
+			return err
+		}
+	}
+	tags := q.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	encoded, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO quotes (id, quote, author, tags, date) VALUES (?, ?, ?, ?, ?)`,
+		q.ID, q.Quote, q.Author, string(encoded), q.Date.UTC().Format(sqliteTime))
+	return err
+}
+
+// placeholders returns n comma-separated parameters.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,79 @@
+// Package store keeps the quotes served by the crud example.
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNotFound  = errors.New("quote not found")
+	ErrExists    = errors.New("quote id already in use")
+	ErrInvalidID = errors.New("quote id must be positive")
+)
+
+// Quote contains information about one quote
+type Quote struct {
+	ID     int64     `json:"id"`
+	Quote  string    `json:"quote"`
+	Author string    `json:"author,omitempty"`
+	Tags   []string  `json:"tags,omitempty"`
+	Date   time.Time `json:"date"`
+}
+
+// QuotePatch holds the fields to change in an update; nil fields are left
+// alone.
+type QuotePatch struct {
+	Quote  *string
+	Author *string
+	Tags   []string
+	// SetTags distinguishes clearing the tags from leaving them alone.
+	SetTags bool
+	Date    *time.Time
+}
+
+func (p QuotePatch) apply(q *Quote) {
+	if p.Quote != nil {
+		q.Quote = *p.Quote
+	}
+	if p.Author != nil {
+		q.Author = *p.Author
+	}
+	if p.SetTags {
+		q.Tags = append([]string(nil), p.Tags...)
+	}
+	if p.Date != nil {
+		q.Date = *p.Date
+	}
+}
+
+// QuoteStore is implemented by every quote backend. List filters and
+// orders the quotes itself, as described by the Query. Create fails with
+// ErrExists if the ID is taken.
+type QuoteStore interface {
+	AuthorStore
+	TagStore
+
+	Get(ctx context.Context, id int64) (Quote, error)
+	List(ctx context.Context, query Query) ([]Quote, error)
+	Create(ctx context.Context, q Quote) (Quote, error)
+	Update(ctx context.Context, id int64, patch QuotePatch) (Quote, error)
+	Delete(ctx context.Context, id int64) (Quote, error)
+	Close() error
+}
+
+func sortQuotes(quotes []Quote, order Order) {
+	sort.Slice(quotes, func(i, j int) bool {
+		return order.Less(quotes[i], quotes[j])
+	})
+}
+
+// clone copies q so callers cannot change a stored quote through its tags.
+func clone(q Quote) Quote {
+	if q.Tags != nil {
+		q.Tags = append([]string(nil), q.Tags...)
+	}
+	return q
+}
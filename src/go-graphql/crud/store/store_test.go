@@ -0,0 +1,156 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-graphdl/crud/store"
+	"go-graphdl/crud/store/storetest"
+)
+
+func TestMemory(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.QuoteStore {
+		return store.NewMemory()
+	})
+}
+
+func TestJSONFile(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.QuoteStore {
+		s, err := store.OpenJSONFile(filepath.Join(t.TempDir(), "data.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestSQLite(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.QuoteStore {
+		s, err := store.OpenSQLite(filepath.Join(t.TempDir(), "quotes.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestJSONFileFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	ioutil.WriteFile(path, []byte(`{"7": {"id": 7, "quote": "Hello", "date": "2018-09-22T12:42:31Z"}}`), 0644)
+	ctx := context.Background()
+
+	s, err := store.OpenJSONFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q, err := s.Get(ctx, 7); err != nil || q.Quote != "Hello" {
+		t.Fatalf("Get(7) = %+v, %v", q, err)
+	}
+	s.Delete(ctx, 7)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := ioutil.ReadFile(path)
+	if strings.TrimSpace(string(content)) != "{}" {
+		t.Errorf("file after delete = %s", content)
+	}
+}
+
+func TestSQLiteMigratesDates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	s, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Dates used to be RFC 3339 of varying width, which sorts wrongly as
+	// text: "12:00:00Z" comes after "12:00:00.5Z".
+	db, _ := sql.Open("sqlite", path)
+	_, err = db.Exec(`INSERT INTO quotes (id, quote, date) VALUES
+		(1, 'Whole', '2018-09-22T12:00:00Z'),
+		(2, 'Half', '2018-09-22T12:00:00.5Z'),
+		(3, 'Zoned', '2018-09-22T13:00:00.25+02:00')`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = store.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	quotes, err := s.List(context.Background(), store.Query{Order: store.OrderDateAsc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, q := range quotes {
+		names = append(names, q.Quote)
+	}
+	if strings.Join(names, " ") != "Zoned Whole Half" {
+		t.Errorf("by date = %v, want [Zoned Whole Half]", names)
+	}
+}
+
+func TestJSONFileKeepsAuthors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	ctx := context.Background()
+
+	s, _ := store.OpenJSONFile(path)
+	s.Create(ctx, store.Quote{ID: 1, Quote: "Hi", Author: "Ann"})
+	bio := "Says hi"
+	s.UpdateAuthor(ctx, 1, store.AuthorPatch{Bio: &bio})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "data.authors.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := store.OpenJSONFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, err := reopened.GetAuthor(ctx, 1); err != nil || a.Name != "Ann" || a.Bio != bio {
+		t.Errorf("GetAuthor(1) = %+v, %v", a, err)
+	}
+}
+
+func TestSQLiteAddsMissingAuthors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotes.db")
+	// A database from before authors had a table of their own.
+	db, _ := sql.Open("sqlite", path)
+	_, err := db.Exec(`CREATE TABLE quotes (
+		id INTEGER PRIMARY KEY, quote TEXT NOT NULL, author TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '[]', date TEXT NOT NULL);
+	INSERT INTO quotes (id, quote, author, date) VALUES
+		(1, 'One', 'Bob', '2018-09-22T12:00:00Z'),
+		(2, 'Two', 'Ann', '2018-09-22T12:00:00Z'),
+		(3, 'Three', 'Bob', '2018-09-22T12:00:00Z'),
+		(4, 'Four', '', '2018-09-22T12:00:00Z')`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := store.OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	authors, err := s.ListAuthors(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(authors) != 2 || authors[0].Name != "Bob" || authors[1].Name != "Ann" {
+		t.Errorf("authors = %v, want Bob then Ann", authors)
+	}
+}
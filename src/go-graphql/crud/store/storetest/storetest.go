@@ -0,0 +1,463 @@
+// Package storetest is a conformance suite for store.QuoteStore
+// implementations.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+)
+
+// Run checks the behaviour every QuoteStore must share. open returns an
+// empty store; it is called once per subtest.
+func Run(t *testing.T, open func(t *testing.T) store.QuoteStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s store.QuoteStore)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"ListOrderedByID", testListOrderedByID},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"NotFound", testNotFound},
+		{"UniqueIDs", testUniqueIDs},
+		{"ReturnsCopies", testReturnsCopies},
+		{"ListQuery", testListQuery},
+		{"Authors", testAuthors},
+		{"RenameAuthor", testRenameAuthor},
+		{"Tags", testTags},
+		{"RetagFailures", testRetagFailures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := open(t)
+			t.Cleanup(func() { s.Close() })
+			tt.test(t, s)
+		})
+	}
+}
+
+var date = time.Date(2018, 9, 22, 12, 42, 31, 0, time.UTC)
+
+func sample(id int64) store.Quote {
+	return store.Quote{
+		ID:     id,
+		Quote:  "Its a nice day",
+		Author: "John Hill",
+		Tags:   []string{"peace", "gh"},
+		Date:   date,
+	}
+}
+
+// assertQuote compares quotes, treating equal instants in different
+// locations as the same date.
+func assertQuote(t *testing.T, got, want store.Quote) {
+	t.Helper()
+	if !got.Date.Equal(want.Date) {
+		t.Errorf("date = %v, want %v", got.Date, want.Date)
+	}
+	got.Date, want.Date = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("quote = %+v, want %+v", got, want)
+	}
+}
+
+func testCreateAndGet(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	created, err := s.Create(ctx, sample(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertQuote(t, created, sample(1))
+
+	got, err := s.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertQuote(t, got, sample(1))
+
+	untagged := store.Quote{ID: 2, Quote: "Bare", Date: date}
+	s.Create(ctx, untagged)
+	got, _ = s.Get(ctx, 2)
+	assertQuote(t, got, untagged)
+}
+
+func testListOrderedByID(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	if quotes, err := s.List(ctx, store.Query{}); err != nil || len(quotes) != 0 {
+		t.Fatalf("empty store List = %v, %v", quotes, err)
+	}
+	for _, id := range []int64{30, 10, 20} {
+		if _, err := s.Create(ctx, sample(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	quotes, err := s.List(ctx, store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, q := range quotes {
+		ids = append(ids, q.ID)
+	}
+	if !reflect.DeepEqual(ids, []int64{10, 20, 30}) {
+		t.Errorf("ids = %v, want [10 20 30]", ids)
+	}
+}
+
+func testUpdate(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	s.Create(ctx, sample(1))
+
+	author := "Demo Hill"
+	updated, err := s.Update(ctx, 1, store.QuotePatch{Author: &author})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sample(1)
+	want.Author = author
+	assertQuote(t, updated, want)
+
+	later := date.Add(time.Hour)
+	text := "Its a nicer day"
+	s.Update(ctx, 1, store.QuotePatch{Quote: &text, Date: &later, Tags: []string{"joy"}, SetTags: true})
+	want.Quote, want.Date, want.Tags = text, later, []string{"joy"}
+	got, _ := s.Get(ctx, 1)
+	assertQuote(t, got, want)
+
+	s.Update(ctx, 1, store.QuotePatch{SetTags: true})
+	got, _ = s.Get(ctx, 1)
+	want.Tags = nil
+	assertQuote(t, got, want)
+}
+
+func testDelete(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	s.Create(ctx, sample(1))
+	s.Create(ctx, sample(2))
+
+	deleted, err := s.Delete(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertQuote(t, deleted, sample(1))
+	if _, err := s.Get(ctx, 1); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get after Delete err = %v, want ErrNotFound", err)
+	}
+	if quotes, _ := s.List(ctx, store.Query{}); len(quotes) != 1 || quotes[0].ID != 2 {
+		t.Errorf("List after Delete = %v", quotes)
+	}
+}
+
+func testNotFound(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	if _, err := s.Get(ctx, 404); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Update(ctx, 404, store.QuotePatch{}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Update err = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Delete(ctx, 404); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Delete err = %v, want ErrNotFound", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	q := sample(1)
+	s.Create(ctx, q)
+	q.Tags[0] = "changed"
+
+	got, _ := s.Get(ctx, 1)
+	got.Tags[1] = "changed"
+	got, _ = s.Get(ctx, 1)
+	assertQuote(t, got, sample(1))
+}
+
+func testUniqueIDs(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	s.Create(ctx, sample(1))
+
+	duplicate := sample(1)
+	duplicate.Quote = "Overwritten"
+	if _, err := s.Create(ctx, duplicate); !errors.Is(err, store.ErrExists) {
+		t.Errorf("Create with a used ID err = %v, want ErrExists", err)
+	}
+	got, _ := s.Get(ctx, 1)
+	assertQuote(t, got, sample(1))
+
+	if _, err := s.Create(ctx, sample(0)); !errors.Is(err, store.ErrInvalidID) {
+		t.Errorf("Create with ID 0 err = %v, want ErrInvalidID", err)
+	}
+}
+
+func testListQuery(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return date.AddDate(0, 0, d) }
+	for _, q := range []store.Quote{
+		{ID: 1, Quote: "Its a nice day", Author: "John Hill", Tags: []string{"peace", "gh"}, Date: day(0)},
+		{ID: 2, Quote: "L'été est beau", Author: "Ann", Tags: []string{"summer"}, Date: day(2)},
+		{ID: 3, Quote: "Another NICE one", Author: "John Hill", Tags: []string{"gh"}, Date: day(1)},
+		{ID: 4, Quote: "Untagged", Author: "Ann", Date: day(1)},
+		// Same instant as quote 2 in another zone, with a fraction.
+		{ID: 5, Quote: "Later", Tags: []string{"peace", "summer"}, Date: day(2).In(time.FixedZone("X", 3600)).Add(time.Millisecond)},
+	} {
+		if _, err := s.Create(ctx, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since, until := day(1), day(2)
+	for _, tt := range []struct {
+		name  string
+		query store.Query
+		ids   []int64
+	}{
+		{"all", store.Query{}, []int64{1, 2, 3, 4, 5}},
+		{"author", store.Query{Author: "John Hill"}, []int64{1, 3}},
+		{"author is exact", store.Query{Author: "john hill"}, nil},
+		{"any tags", store.Query{AnyTags: []string{"gh", "summer"}}, []int64{1, 2, 3, 5}},
+		{"all tags", store.Query{AllTags: []string{"peace", "summer"}}, []int64{5}},
+		{"any and all tags", store.Query{AnyTags: []string{"gh", "summer"}, AllTags: []string{"peace"}}, []int64{1, 5}},
+		{"since", store.Query{Since: &since}, []int64{2, 3, 4, 5}},
+		{"until", store.Query{Until: &until}, []int64{1, 3, 4}},
+		{"date range", store.Query{Since: &since, Until: &until}, []int64{3, 4}},
+		{"text ignores case", store.Query{Text: "nice"}, []int64{1, 3}},
+		{"text ignores case beyond ASCII", store.Query{Text: "ÉTÉ"}, []int64{2}},
+		{"combined", store.Query{Author: "Ann", Since: &since, Text: "tag"}, []int64{4}},
+		{"id desc", store.Query{Order: store.OrderIDDesc}, []int64{5, 4, 3, 2, 1}},
+		{"date asc", store.Query{Order: store.OrderDateAsc}, []int64{1, 3, 4, 2, 5}},
+		{"date desc", store.Query{Order: store.OrderDateDesc}, []int64{5, 2, 4, 3, 1}},
+		{"filtered and ordered", store.Query{Author: "John Hill", Order: store.OrderDateDesc}, []int64{3, 1}},
+	} {
+		quotes, err := s.List(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var ids []int64
+		for _, q := range quotes {
+			if !tt.query.Match(q) {
+				t.Errorf("%s: Match(%d) = false", tt.name, q.ID)
+			}
+			ids = append(ids, q.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("%s: ids = %v, want %v", tt.name, ids, tt.ids)
+		}
+	}
+}
+
+func authorNames(t *testing.T, s store.QuoteStore) []string {
+	t.Helper()
+	authors, err := s.ListAuthors(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for i, a := range authors {
+		if i > 0 && a.ID <= authors[i-1].ID {
+			t.Errorf("authors not ordered by ID: %v", authors)
+		}
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func testAuthors(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	if names := authorNames(t, s); len(names) != 0 {
+		t.Fatalf("empty store has authors %v", names)
+	}
+	s.Create(ctx, store.Quote{ID: 1, Quote: "One", Author: "Ann", Date: date})
+	s.Create(ctx, store.Quote{ID: 2, Quote: "Two", Author: "Bob", Date: date})
+	s.Create(ctx, store.Quote{ID: 3, Quote: "Three", Author: "Ann", Date: date})
+	s.Create(ctx, store.Quote{ID: 4, Quote: "Anonymous", Date: date})
+	if names := authorNames(t, s); !reflect.DeepEqual(names, []string{"Ann", "Bob"}) {
+		t.Errorf("authors = %v, want [Ann Bob]", names)
+	}
+
+	// Changing a quote's author adds the new one; deleting quotes keeps
+	// their author.
+	cy := "Cy"
+	s.Update(ctx, 2, store.QuotePatch{Author: &cy})
+	s.Delete(ctx, 2)
+	if names := authorNames(t, s); !reflect.DeepEqual(names, []string{"Ann", "Bob", "Cy"}) {
+		t.Errorf("authors = %v, want [Ann Bob Cy]", names)
+	}
+
+	found, err := s.AuthorsByName(ctx, []string{"Cy", "Nobody", "Ann", "Cy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]store.Author{}
+	for _, a := range found {
+		byName[a.Name] = a
+	}
+	if len(found) != 2 || byName["Ann"].ID == 0 || byName["Cy"].ID == 0 {
+		t.Errorf("AuthorsByName = %v", found)
+	}
+	if found, _ := s.AuthorsByName(ctx, nil); len(found) != 0 {
+		t.Errorf("AuthorsByName(nil) = %v", found)
+	}
+
+	got, err := s.GetAuthor(ctx, byName["Ann"].ID)
+	if err != nil || got != byName["Ann"] {
+		t.Errorf("GetAuthor = %+v, %v, want %+v", got, err, byName["Ann"])
+	}
+	if _, err := s.GetAuthor(ctx, 404); !errors.Is(err, store.ErrAuthorNotFound) {
+		t.Errorf("GetAuthor(404) err = %v, want ErrAuthorNotFound", err)
+	}
+
+	bio := "Writes things down"
+	updated, err := s.UpdateAuthor(ctx, byName["Ann"].ID, store.AuthorPatch{Bio: &bio})
+	if err != nil || updated.Bio != bio || updated.Name != "Ann" {
+		t.Errorf("UpdateAuthor = %+v, %v", updated, err)
+	}
+	if got, _ := s.GetAuthor(ctx, updated.ID); got.Bio != bio {
+		t.Errorf("bio after update = %q", got.Bio)
+	}
+	if _, err := s.UpdateAuthor(ctx, 404, store.AuthorPatch{Bio: &bio}); !errors.Is(err, store.ErrAuthorNotFound) {
+		t.Errorf("UpdateAuthor(404) err = %v, want ErrAuthorNotFound", err)
+	}
+}
+
+func testRenameAuthor(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	s.Create(ctx, store.Quote{ID: 1, Quote: "One", Author: "Ann", Date: date})
+	s.Create(ctx, store.Quote{ID: 2, Quote: "Two", Author: "Bob", Date: date})
+	s.Create(ctx, store.Quote{ID: 3, Quote: "Three", Author: "Ann", Date: date})
+	authors, _ := s.AuthorsByName(ctx, []string{"Ann"})
+	ann := authors[0]
+
+	name := "Anne"
+	renamed, err := s.UpdateAuthor(ctx, ann.ID, store.AuthorPatch{Name: &name})
+	if err != nil || renamed.Name != name || renamed.ID != ann.ID {
+		t.Fatalf("rename = %+v, %v", renamed, err)
+	}
+	quotes, _ := s.List(ctx, store.Query{Author: name})
+	if len(quotes) != 2 {
+		t.Errorf("quotes by %s = %v, want 2", name, quotes)
+	}
+	if quotes, _ := s.List(ctx, store.Query{Author: "Ann"}); len(quotes) != 0 {
+		t.Errorf("quotes still by Ann: %v", quotes)
+	}
+	if found, _ := s.AuthorsByName(ctx, []string{"Ann"}); len(found) != 0 {
+		t.Errorf("Ann still found: %v", found)
+	}
+
+	// A rename that fails changes nothing.
+	bob, empty := "Bob", ""
+	if _, err := s.UpdateAuthor(ctx, ann.ID, store.AuthorPatch{Name: &bob}); !errors.Is(err, store.ErrAuthorExists) {
+		t.Errorf("rename to a taken name err = %v, want ErrAuthorExists", err)
+	}
+	if _, err := s.UpdateAuthor(ctx, ann.ID, store.AuthorPatch{Name: &empty}); !errors.Is(err, store.ErrAuthorName) {
+		t.Errorf("rename to \"\" err = %v, want ErrAuthorName", err)
+	}
+	if q, _ := s.Get(ctx, 1); q.Author != name {
+		t.Errorf("quote 1 author = %q, want %q", q.Author, name)
+	}
+	if names := authorNames(t, s); !reflect.DeepEqual(names, []string{name, "Bob"}) {
+		t.Errorf("authors = %v", names)
+	}
+}
+
+// allTags returns the tags of every quote by ID.
+func allTags(t *testing.T, s store.QuoteStore) map[int64][]string {
+	t.Helper()
+	quotes, err := s.List(context.Background(), store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[int64][]string{}
+	for _, q := range quotes {
+		tags[q.ID] = q.Tags
+	}
+	return tags
+}
+
+func createTagged(t *testing.T, s store.QuoteStore) {
+	t.Helper()
+	for id, tags := range map[int64][]string{
+		1: {"peace", "gh"},
+		2: {"gh", "joy"},
+		3: {"life", "peace", "love"},
+		4: nil,
+	} {
+		if _, err := s.Create(context.Background(), store.Quote{ID: id, Quote: "Tagged", Tags: tags, Date: date}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testTags(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	if tags, err := s.Tags(ctx); err != nil || len(tags) != 0 {
+		t.Fatalf("empty store Tags = %v, %v", tags, err)
+	}
+	createTagged(t, s)
+
+	tags, err := s.Tags(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []store.Tag{{Name: "gh", Count: 2}, {Name: "joy", Count: 1}, {Name: "life", Count: 1}, {Name: "love", Count: 1}, {Name: "peace", Count: 2}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags = %v, want %v", tags, want)
+	}
+
+	tag, err := s.RenameTag(ctx, "gh", "github")
+	if err != nil || tag != (store.Tag{Name: "github", Count: 2}) {
+		t.Errorf("RenameTag = %v, %v", tag, err)
+	}
+	// Quote 3 carries two of the merged tags and keeps the first place.
+	tag, err = s.MergeTags(ctx, []string{"life", "love", "joy"}, "peace")
+	if err != nil || tag != (store.Tag{Name: "peace", Count: 3}) {
+		t.Errorf("MergeTags = %v, %v", tag, err)
+	}
+	wantTags := map[int64][]string{
+		1: {"peace", "github"},
+		2: {"github", "peace"},
+		3: {"peace"},
+		4: nil,
+	}
+	if got := allTags(t, s); !reflect.DeepEqual(got, wantTags) {
+		t.Errorf("tags = %v, want %v", got, wantTags)
+	}
+
+	tag, err = s.MergeTags(ctx, []string{"github"}, "new")
+	if err != nil || tag != (store.Tag{Name: "new", Count: 2}) {
+		t.Errorf("MergeTags into a new tag = %v, %v", tag, err)
+	}
+	if tags, _ := s.Tags(ctx); !reflect.DeepEqual(tags, []store.Tag{{Name: "new", Count: 2}, {Name: "peace", Count: 3}}) {
+		t.Errorf("Tags after merging = %v", tags)
+	}
+}
+
+func testRetagFailures(t *testing.T, s store.QuoteStore) {
+	ctx := context.Background()
+	createTagged(t, s)
+	before := allTags(t, s)
+
+	for _, tt := range []struct {
+		name string
+		do   func() (store.Tag, error)
+		want error
+	}{
+		{"rename missing", func() (store.Tag, error) { return s.RenameTag(ctx, "nope", "x") }, store.ErrTagNotFound},
+		{"rename to taken", func() (store.Tag, error) { return s.RenameTag(ctx, "gh", "peace") }, store.ErrTagExists},
+		{"rename to empty", func() (store.Tag, error) { return s.RenameTag(ctx, "gh", "") }, store.ErrTagName},
+		{"merge missing", func() (store.Tag, error) { return s.MergeTags(ctx, []string{"gh", "nope"}, "x") }, store.ErrTagNotFound},
+		{"merge into empty", func() (store.Tag, error) { return s.MergeTags(ctx, []string{"gh"}, "") }, store.ErrTagName},
+	} {
+		if _, err := tt.do(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if after := allTags(t, s); !reflect.DeepEqual(after, before) {
+		t.Errorf("failed retagging changed tags from %v to %v", before, after)
+	}
+}
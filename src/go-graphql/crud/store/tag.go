@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already in use")
+	ErrTagName     = errors.New("tag must not be empty")
+)
+
+// Tag is a tag in use and the number of quotes carrying it.
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagStore is the tag half of a QuoteStore. Tags only exist on quotes, so
+// changing them rewrites every quote carrying them, atomically.
+type TagStore interface {
+	// Tags returns every tag in use, by name.
+	Tags(ctx context.Context) ([]Tag, error)
+	// RenameTag renames from to a tag that is not in use yet.
+	RenameTag(ctx context.Context, from, to string) (Tag, error)
+	// MergeTags replaces the tags in from with into, which may already be
+	// in use. Quotes carrying several of them end up with into once.
+	MergeTags(ctx context.Context, from []string, into string) (Tag, error)
+}
+
+// retag replaces the tags in from with into, keeping the position of the
+// first one replaced, and reports whether tags changed.
+func retag(tags []string, from map[string]bool, into string) ([]string, bool) {
+	changed := false
+	for _, tag := range tags {
+		if from[tag] {
+			changed = true
+		}
+	}
+	if !changed {
+		return tags, false
+	}
+	result := make([]string, 0, len(tags))
+	placed := false
+	for _, tag := range tags {
+		if from[tag] || tag == into {
+			if placed {
+				continue
+			}
+			tag, placed = into, true
+		}
+		result = append(result, tag)
+	}
+	return result, true
+}
+
+// checkRetag validates the arguments of RenameTag and MergeTags, given the
+// tags in use, and returns the set of tags to replace.
+func checkRetag(inUse map[string]int, from []string, into string, rename bool) (map[string]bool, error) {
+	if into == "" {
+		return nil, ErrTagName
+	}
+	set := map[string]bool{}
+	for _, tag := range from {
+		if inUse[tag] == 0 {
+			return nil, ErrTagNotFound
+		}
+		set[tag] = true
+	}
+	if rename && inUse[into] > 0 && !set[into] {
+		return nil, ErrTagExists
+	}
+	return set, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"go-graphdl/crud/gqlerr"
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+// Tag is a tag in use and the number of quotes carrying it
+type Tag = store.Tag
+
+var tagType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Tag",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"count": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Int),
+				Description: "Number of quotes carrying the tag",
+			},
+			"quotes": &graphql.Field{
+				Type:        graphql.NewNonNull(quoteConnectionType),
+				Description: "Page through the quotes carrying the tag",
+				Args:        mergeArgs(listArgs, connectionArgs),
+				Resolve: gqlerr.Resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					l, err := loadersFrom(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					query := queryFrom(p.Args)
+					query.AllTags = append(query.AllTags, p.Source.(Tag).Name)
+					list, err := l.quotes.List(p.Context, query)
+					if err != nil {
+						return nil, err
+					}
+					return paginate(list, query.Order, pageArgsFrom(p.Args))
+				}),
+			},
+		},
+	},
+)
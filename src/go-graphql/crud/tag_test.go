@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/graphql-go/graphql"
+)
+
+func tagSchema(t *testing.T) (graphql.Schema, store.QuoteStore) {
+	t.Helper()
+	date := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	quotes := store.NewMemory(
+		Quote{ID: 1, Quote: "One", Tags: []string{"peace", "gh"}, Date: date},
+		Quote{ID: 2, Quote: "Two", Tags: []string{"gh"}, Date: date.AddDate(0, 0, 1)},
+		Quote{ID: 3, Quote: "Three", Tags: []string{"love"}, Date: date},
+	)
+	schema, err := newSchema(quotes, store.NewSequence(3), newEventBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, quotes
+}
+
+func TestTags(t *testing.T) {
+	schema, quotes := tagSchema(t)
+	data := run(t, schema, quotes, `{
+		tags {
+			name
+			count
+			quotes(orderBy: DATE_DESC, first: 1) { totalCount edges { node { id } } }
+		}
+	}`)
+
+	type tagPage struct {
+		name       string
+		count      int
+		totalCount int
+		first      string
+	}
+	var got []tagPage
+	for _, tag := range data["tags"].([]interface{}) {
+		tag := tag.(map[string]interface{})
+		conn := tag["quotes"].(map[string]interface{})
+		edges := conn["edges"].([]interface{})
+		got = append(got, tagPage{
+			name:       tag["name"].(string),
+			count:      tag["count"].(int),
+			totalCount: conn["totalCount"].(int),
+			first:      edges[0].(map[string]interface{})["node"].(map[string]interface{})["id"].(string),
+		})
+	}
+	want := []tagPage{
+		{"gh", 2, 2, "2"},
+		{"love", 1, 1, "3"},
+		{"peace", 1, 1, "1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %+v, want %+v", got, want)
+	}
+}
+
+func TestRetagMutations(t *testing.T) {
+	schema, quotes := tagSchema(t)
+	data := run(t, schema, quotes, `mutation {
+		renamed: renameTag(from: "gh", to: "github") { name count }
+		merged: mergeTags(from: ["love", "github"], into: "peace") { name count }
+	}`)
+
+	if want := map[string]interface{}{"name": "github", "count": 2}; !reflect.DeepEqual(data["renamed"], want) {
+		t.Errorf("renameTag = %v, want %v", data["renamed"], want)
+	}
+	if want := map[string]interface{}{"name": "peace", "count": 3}; !reflect.DeepEqual(data["merged"], want) {
+		t.Errorf("mergeTags = %v, want %v", data["merged"], want)
+	}
+	tags, _ := quotes.Tags(context.Background())
+	if !reflect.DeepEqual(tags, []Tag{{Name: "peace", Count: 3}}) {
+		t.Errorf("tags = %v", tags)
+	}
+}
+
+func TestRenameTagToTakenFails(t *testing.T) {
+	schema, quotes := tagSchema(t)
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { renameTag(from: "gh", to: "love") { name } }`,
+		Context:       withLoaders(context.Background(), quotes),
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != store.ErrTagExists.Error() {
+		t.Errorf("errors = %v, want %q", result.Errors, store.ErrTagExists)
+	}
+	if tags, _ := quotes.Tags(context.Background()); len(tags) != 3 {
+		t.Errorf("tags after failed rename = %v", tags)
+	}
+}
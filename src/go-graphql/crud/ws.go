@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/coder/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// The graphql-transport-ws protocol:
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const subprotocolGraphQLWS = "graphql-transport-ws"
+
+const (
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// Close codes of the protocol.
+const (
+	closeBadRequest       websocket.StatusCode = 4400
+	closeUnauthorized     websocket.StatusCode = 4401
+	closeBadSubprotocol   websocket.StatusCode = 4406
+	closeInitTimeout      websocket.StatusCode = 4408
+	closeSubscriberExists websocket.StatusCode = 4409
+	closeTooManyInits     websocket.StatusCode = 4429
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsHandler serves schema over WebSocket, running subscriptions off the
+// event bus. Every event is sent by executing the subscription again, with
+// the event's quote as the root value.
+type wsHandler struct {
+	schema graphql.Schema
+	events *eventBus
+	quotes store.QuoteStore
+	// initTimeout is how long a client may take to send connection_init.
+	initTimeout time.Duration
+}
+
+func graphQLWSHandler(schema graphql.Schema, events *eventBus, quotes store.QuoteStore) *wsHandler {
+	return &wsHandler{
+		schema:      schema,
+		events:      events,
+		quotes:      quotes,
+		initTimeout: 10 * time.Second,
+	}
+}
+
+// webSocketOr sends WebSocket upgrade requests to ws and the others to
+// next.
+func webSocketOr(ws, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{subprotocolGraphQLWS}})
+	if err != nil {
+		// Accept has written the response.
+		return
+	}
+	if conn.Subprotocol() != subprotocolGraphQLWS {
+		conn.Close(closeBadSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+	conn.SetReadLimit(maxRequestBytes)
+
+	c := &wsConn{h: h, conn: conn, ops: map[string]*wsOperation{}}
+	c.serve(r.Context())
+}
+
+// wsConn is one client connection.
+type wsConn struct {
+	h    *wsHandler
+	conn *websocket.Conn
+
+	mu   sync.Mutex
+	init bool
+	ops  map[string]*wsOperation
+	// running counts the operations' goroutines.
+	running sync.WaitGroup
+}
+
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+func (c *wsConn) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.running.Wait()
+		c.conn.CloseNow()
+	}()
+
+	initTimer := time.AfterFunc(c.h.initTimeout, func() {
+		c.mu.Lock()
+		init := c.init
+		c.mu.Unlock()
+		if !init {
+			c.conn.Close(closeInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	for {
+		typ, data, err := c.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+		var msg wsMessage
+		if typ != websocket.MessageText || json.Unmarshal(data, &msg) != nil {
+			c.conn.Close(closeBadRequest, "Invalid message")
+			return
+		}
+
+		switch msg.Type {
+		case msgConnectionInit:
+			c.mu.Lock()
+			again := c.init
+			c.init = true
+			c.mu.Unlock()
+			if again {
+				c.conn.Close(closeTooManyInits, "Too many initialisation requests")
+				return
+			}
+			c.write(ctx, wsMessage{Type: msgConnectionAck})
+		case msgPing:
+			c.write(ctx, wsMessage{Type: msgPong})
+		case msgPong:
+		case msgSubscribe:
+			c.mu.Lock()
+			init := c.init
+			c.mu.Unlock()
+			if !init {
+				c.conn.Close(closeUnauthorized, "Unauthorized")
+				return
+			}
+			var req graphQLRequest
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				c.conn.Close(closeBadRequest, "Invalid subscribe message")
+				return
+			}
+			if !c.start(ctx, msg.ID, req) {
+				c.conn.Close(closeSubscriberExists, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+		case msgComplete:
+			c.mu.Lock()
+			if op, ok := c.ops[msg.ID]; ok {
+				op.cancel()
+				delete(c.ops, msg.ID)
+			}
+			c.mu.Unlock()
+		default:
+			c.conn.Close(closeBadRequest, "Unknown message type")
+			return
+		}
+	}
+}
+
+// start runs the operation asking for id. It reports false if id is
+// taken by an operation still running.
+func (c *wsConn) start(ctx context.Context, id string, req graphQLRequest) bool {
+	c.mu.Lock()
+	_, taken := c.ops[id]
+	c.mu.Unlock()
+	if taken {
+		return false
+	}
+
+	doc, op, errs := prepare(c.h.schema, req)
+	var topic string
+	if errs == nil && op.Operation == ast.OperationTypeSubscription {
+		var err error
+		if topic, err = subscriptionTopic(op); err != nil {
+			errs = errorResult(err).Errors
+		}
+	}
+	if errs != nil {
+		c.write(ctx, wsMessage{ID: id, Type: msgError, Payload: marshal(errs)})
+		return true
+	}
+
+	// Subscribe before reading the next message, so that the client sees
+	// the changes made by any operation it sends after this one.
+	var events <-chan Quote
+	unsubscribe := func() {}
+	if topic != "" {
+		events, unsubscribe = c.h.events.Subscribe(topic)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	self := &wsOperation{cancel: cancel}
+	c.mu.Lock()
+	c.ops[id] = self
+	c.mu.Unlock()
+
+	c.running.Add(1)
+	go func() {
+		defer c.running.Done()
+		defer cancel()
+		defer unsubscribe()
+
+		params := graphql.ExecuteParams{
+			Schema:        c.h.schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+		}
+		var final wsMessage
+		if topic == "" {
+			params.Context = withLoaders(ctx, c.h.quotes)
+			c.write(ctx, wsMessage{ID: id, Type: msgNext, Payload: marshal(graphql.Execute(params))})
+			final = wsMessage{ID: id, Type: msgComplete}
+		} else {
+			final = c.forward(ctx, id, params, topic, events)
+		}
+
+		// Free the id before saying so, unless the client has done
+		// both already.
+		c.mu.Lock()
+		current := c.ops[id] == self
+		if current {
+			delete(c.ops, id)
+		}
+		c.mu.Unlock()
+		if current && final.Type != "" {
+			c.write(ctx, final)
+		}
+	}()
+	return true
+}
+
+// forward sends an execution result for every event until ctx is done or
+// the bus drops the subscriber, and returns the message ending the
+// subscription, if any.
+func (c *wsConn) forward(ctx context.Context, id string, params graphql.ExecuteParams, topic string, events <-chan Quote) wsMessage {
+	for {
+		select {
+		case <-ctx.Done():
+			return wsMessage{}
+		case q, ok := <-events:
+			if !ok {
+				err := fmt.Errorf("subscription fell more than %d events behind", subscriberBuffer)
+				return wsMessage{ID: id, Type: msgError, Payload: marshal(errorResult(err).Errors)}
+			}
+			params.Root = map[string]interface{}{topic: q}
+			params.Context = withLoaders(ctx, c.h.quotes)
+			c.write(ctx, wsMessage{ID: id, Type: msgNext, Payload: marshal(graphql.Execute(params))})
+		}
+	}
+}
+
+func (c *wsConn) write(ctx context.Context, msg wsMessage) {
+	data, _ := json.Marshal(msg)
+	c.conn.Write(ctx, websocket.MessageText, data)
+}
+
+func marshal(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+// subscriptionTopic returns the topic of a subscription, which must select
+// a single field as the spec requires.
+func subscriptionTopic(op *ast.OperationDefinition) (string, error) {
+	selections := op.SelectionSet.Selections
+	if len(selections) == 1 {
+		if field, ok := selections[0].(*ast.Field); ok && !strings.HasPrefix(field.Name.Value, "__") {
+			return field.Name.Value, nil
+		}
+	}
+	return "", errors.New("subscriptions must select exactly one field")
+}
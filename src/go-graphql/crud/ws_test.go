@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-graphdl/crud/store"
+
+	"github.com/coder/websocket"
+)
+
+type wsServer struct {
+	*httptest.Server
+	ws     *wsHandler
+	quotes store.QuoteStore
+}
+
+func newWSServer(t *testing.T) *wsServer {
+	t.Helper()
+	quotes := store.NewMemory(Quote{ID: 1, Quote: "Hello world", Author: "John Will", Date: time.Now()})
+	events := newEventBus()
+	schema, err := newSchema(quotes, store.NewSequence(1), events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := graphQLWSHandler(schema, events, quotes)
+	srv := httptest.NewServer(webSocketOr(ws, loaderMiddleware(quotes, graphQLHandler(schema))))
+	t.Cleanup(srv.Close)
+	return &wsServer{Server: srv, ws: ws, quotes: quotes}
+}
+
+type wsClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func (s *wsServer) dial(t *testing.T, subprotocols ...string) *wsClient {
+	t.Helper()
+	if subprotocols == nil {
+		subprotocols = []string{subprotocolGraphQLWS}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(s.URL, "http")+"/graphql",
+		&websocket.DialOptions{Subprotocols: subprotocols})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return &wsClient{t: t, conn: conn}
+}
+
+func (c *wsClient) send(msg string) {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.conn.Write(ctx, websocket.MessageText, []byte(msg)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *wsClient) recv() wsMessage {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, data, err := c.conn.Read(ctx)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var msg wsMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.t.Fatalf("decoding %s: %v", data, err)
+	}
+	return msg
+}
+
+// expect reads the next message and checks its id and type.
+func (c *wsClient) expect(id, typ string) wsMessage {
+	c.t.Helper()
+	msg := c.recv()
+	if msg.ID != id || msg.Type != typ {
+		c.t.Fatalf("got %s %q %s, want %s %q", msg.Type, msg.ID, msg.Payload, typ, id)
+	}
+	return msg
+}
+
+// sync makes sure the server has handled every message sent so far.
+func (c *wsClient) sync() {
+	c.t.Helper()
+	c.send(`{"type": "ping"}`)
+	c.expect("", msgPong)
+}
+
+// expectClose reads until the server closes the connection with code.
+func (c *wsClient) expectClose(code websocket.StatusCode) {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for {
+		if _, _, err := c.conn.Read(ctx); err != nil {
+			if got := websocket.CloseStatus(err); got != code {
+				c.t.Fatalf("closed with %d (%v), want %d", got, err, code)
+			}
+			return
+		}
+	}
+}
+
+func (c *wsClient) init() {
+	c.t.Helper()
+	c.send(`{"type": "connection_init"}`)
+	c.expect("", msgConnectionAck)
+}
+
+func quoteOf(t *testing.T, msg wsMessage, field string) map[string]interface{} {
+	t.Helper()
+	var result response
+	if err := json.Unmarshal(msg.Payload, &result); err != nil || len(result.Errors) > 0 {
+		t.Fatalf("payload %s: %v", msg.Payload, err)
+	}
+	quote, _ := result.Data[field].(map[string]interface{})
+	return quote
+}
+
+func TestSubscriptions(t *testing.T) {
+	srv := newWSServer(t)
+	c := srv.dial(t)
+	c.init()
+
+	for id, field := range map[string]string{"c": "quoteCreated", "u": "quoteUpdated", "d": "quoteDeleted"} {
+		c.send(`{"id": "` + id + `", "type": "subscribe", "payload": {"query": "subscription { ` + field + ` { id quote author { name } } }"}}`)
+	}
+	c.sync()
+
+	// Changes made over HTTP reach the subscribers.
+	for _, mutation := range []string{
+		`mutation { create(quote: "New", author: "Ann") { id } }`,
+		`mutation { update(id: 2, quote: "Newer") { id } }`,
+		`mutation { delete(id: 2) { id } }`,
+	} {
+		resp, err := http.Post(srv.URL+"/graphql", "application/graphql", strings.NewReader(mutation))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	created := quoteOf(t, c.expect("c", msgNext), "quoteCreated")
+	if created["id"] != "2" || created["quote"] != "New" || created["author"].(map[string]interface{})["name"] != "Ann" {
+		t.Errorf("quoteCreated = %v", created)
+	}
+	if updated := quoteOf(t, c.expect("u", msgNext), "quoteUpdated"); updated["quote"] != "Newer" {
+		t.Errorf("quoteUpdated = %v", updated)
+	}
+	if deleted := quoteOf(t, c.expect("d", msgNext), "quoteDeleted"); deleted["id"] != "2" {
+		t.Errorf("quoteDeleted = %v", deleted)
+	}
+
+	// Once the client completes a subscription it gets no more events,
+	// and no complete message either.
+	c.send(`{"id": "c", "type": "complete"}`)
+	c.sync()
+	c.send(`{"id": "m", "type": "subscribe", "payload": {"query": "mutation { create(quote: \"Unseen\") { id } }"}}`)
+	if created := quoteOf(t, c.expect("m", msgNext), "create"); created["id"] != "3" {
+		t.Errorf("create = %v", created)
+	}
+	c.expect("m", msgComplete)
+
+	// The id is free again.
+	c.send(`{"id": "c", "type": "subscribe", "payload": {"query": "query Q($id: ID!) { quote(id: $id) { quote } }", "variables": {"id": 3}}}`)
+	if q := quoteOf(t, c.expect("c", msgNext), "quote"); q["quote"] != "Unseen" {
+		t.Errorf("quote = %v", q)
+	}
+	c.expect("c", msgComplete)
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	srv := newWSServer(t)
+	c := srv.dial(t)
+	c.init()
+
+	for _, query := range []string{
+		`subscription { nope { id } }`,
+		`subscription { quoteCreated { id } quoteDeleted { id } }`,
+		`{`,
+	} {
+		payload, _ := json.Marshal(map[string]string{"query": query})
+		c.send(`{"id": "1", "type": "subscribe", "payload": ` + string(payload) + `}`)
+		msg := c.expect("1", msgError)
+		var errs []map[string]interface{}
+		if err := json.Unmarshal(msg.Payload, &errs); err != nil || len(errs) == 0 || errs[0]["message"] == "" {
+			t.Errorf("%s: error payload %s", query, msg.Payload)
+		}
+	}
+	// The failed operations did not keep their id.
+	c.send(`{"id": "1", "type": "subscribe", "payload": {"query": "subscription { quoteCreated { id } }"}}`)
+	c.sync()
+}
+
+func TestProtocolViolationsCloseTheConnection(t *testing.T) {
+	srv := newWSServer(t)
+	subscribe := `{"id": "1", "type": "subscribe", "payload": {"query": "subscription { quoteCreated { id } }"}}`
+
+	for _, tt := range []struct {
+		name     string
+		messages []string
+		code     websocket.StatusCode
+	}{
+		{"subscribe before init", []string{subscribe}, closeUnauthorized},
+		{"second init", []string{`{"type": "connection_init"}`, `{"type": "connection_init"}`}, closeTooManyInits},
+		{"duplicate id", []string{`{"type": "connection_init"}`, subscribe, subscribe}, closeSubscriberExists},
+		{"not JSON", []string{`hello`}, closeBadRequest},
+		{"unknown type", []string{`{"type": "hello"}`}, closeBadRequest},
+		{"subscribe without id", []string{`{"type": "connection_init"}`, `{"type": "subscribe", "payload": {"query": "{ list { id } }"}}`}, closeBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := srv.dial(t)
+			for _, msg := range tt.messages {
+				c.send(msg)
+			}
+			c.expectClose(tt.code)
+		})
+	}
+}
+
+func TestInitTimeout(t *testing.T) {
+	srv := newWSServer(t)
+	srv.ws.initTimeout = 20 * time.Millisecond
+	c := srv.dial(t)
+	c.expectClose(closeInitTimeout)
+}
+
+func TestUnknownSubprotocol(t *testing.T) {
+	srv := newWSServer(t)
+	c := srv.dial(t, "graphql-ws")
+	c.expectClose(closeBadSubprotocol)
+}
+
+func TestSubscriptionOverHTTP(t *testing.T) {
+	rec, resp := serve(t, post("application/json", `{"query": "subscription { quoteCreated { id } }"}`))
+	if rec.Code != http.StatusOK || len(resp.Errors) != 1 || resp.Data != nil {
+		t.Errorf("got %d %+v", rec.Code, resp)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+type engagementPostRequest struct {
+	Kind newsfeed.Engagement `json:"kind" binding:"required"`
+}
+
+// EngagementPost records a reaction, comment or view on a published item,
+// feeding its trending score.
+func EngagementPost(feed newsfeed.Engager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestBody := engagementPostRequest{}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		err := feed.Engage(c.Request.Context(), c.Param("id"), requestBody.Kind)
+		switch {
+		case errors.Is(err, newsfeed.ErrNotFound):
+			abortWithError(c, http.StatusNotFound, err)
+		case err != nil:
+			abortWithError(c, http.StatusBadRequest, err)
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+const feedKey = "newsfeeder.feed"
+
+type feedSettings struct {
+	Moderation bool                `json:"moderation"`
+	Retention  string              `json:"retention,omitempty"`
+	Visibility newsfeed.Visibility `json:"visibility"`
+}
+
+type feedPostRequest struct {
+	Name string `json:"name" binding:"required"`
+	feedSettings
+}
+
+type feedResponse struct {
+	Name string `json:"name"`
+	feedSettings
+}
+
+func (s feedSettings) settings() (newsfeed.Settings, error) {
+	settings := newsfeed.Settings{
+		Moderation: s.Moderation,
+		Visibility: s.Visibility,
+	}
+	if s.Retention != "" {
+		retention, err := time.ParseDuration(s.Retention)
+		if err != nil || retention <= 0 {
+			return settings, errors.New("retention must be a positive duration such as \"72h\"")
+		}
+		settings.Retention = retention
+	}
+	return settings, nil
+}
+
+func newFeedResponse(f newsfeed.Feed) feedResponse {
+	resp := feedResponse{
+		Name: f.Name,
+		feedSettings: feedSettings{
+			Moderation: f.Settings.Moderation,
+			Visibility: f.Settings.Visibility,
+		},
+	}
+	if f.Settings.Retention > 0 {
+		resp.Retention = f.Settings.Retention.String()
+	}
+	return resp
+}
+
+// FeedAccess looks up the :feed route parameter for the handlers that
+// follow it. Private feeds need adminToken as a bearer token.
+func FeedAccess(feeds *newsfeed.Registry, adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, err := feeds.Get(c.Param("feed"))
+		if err != nil {
+			abortWithError(c, http.StatusNotFound, err)
+			return
+		}
+		if f.Settings.Visibility == newsfeed.VisibilityPrivate && !hasToken(c, adminToken) {
+			// Private feeds are indistinguishable from missing ones.
+			abortWithError(c, http.StatusNotFound, newsfeed.ErrFeedNotFound)
+			return
+		}
+		c.Set(feedKey, f)
+	}
+}
+
+// currentFeed returns the feed found by FeedAccess.
+func currentFeed(c *gin.Context) newsfeed.Feed {
+	return c.MustGet(feedKey).(newsfeed.Feed)
+}
+
+// FeedsGet lists the public feeds, and private ones too for requests
+// carrying adminToken.
+func FeedsGet(feeds *newsfeed.Registry, adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := hasToken(c, adminToken)
+		results := []feedResponse{}
+		for _, f := range feeds.List() {
+			if f.Settings.Visibility == newsfeed.VisibilityPublic || admin {
+				results = append(results, newFeedResponse(f))
+			}
+		}
+		c.JSON(http.StatusOK, results)
+	}
+}
+
+// FeedGet returns the feed found by FeedAccess.
+func FeedGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, newFeedResponse(currentFeed(c)))
+	}
+}
+
+func FeedPost(feeds *newsfeed.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestBody := feedPostRequest{}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		settings, err := requestBody.settings()
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		f, err := feeds.Create(requestBody.Name, settings)
+		switch {
+		case errors.Is(err, newsfeed.ErrFeedExists):
+			abortWithError(c, http.StatusConflict, err)
+		case err != nil:
+			abortWithError(c, http.StatusBadRequest, err)
+		default:
+			c.JSON(http.StatusCreated, newFeedResponse(f))
+		}
+	}
+}
+
+// FeedPut replaces a feed's settings.
+func FeedPut(feeds *newsfeed.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestBody := feedSettings{}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		settings, err := requestBody.settings()
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		f, err := feeds.Update(c.Param("feed"), settings)
+		switch {
+		case errors.Is(err, newsfeed.ErrFeedNotFound):
+			abortWithError(c, http.StatusNotFound, err)
+		case err != nil:
+			abortWithError(c, http.StatusBadRequest, err)
+		default:
+			c.JSON(http.StatusOK, newFeedResponse(f))
+		}
+	}
+}
+
+func FeedDelete(feeds *newsfeed.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := feeds.Delete(c.Param("feed"))
+		switch {
+		case errors.Is(err, newsfeed.ErrFeedNotFound):
+			abortWithError(c, http.StatusNotFound, err)
+		case err != nil:
+			abortWithError(c, http.StatusBadRequest, err)
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"newsfeeder/platform/filter"
+	"newsfeeder/platform/media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The handlers below serve the /newsfeed routes' counterparts for the feed
+// found by FeedAccess.
+
+func FeedItemsGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		NewsfeedGet(currentFeed(c).Repo)(c)
+	}
+}
+
+func FeedItemsPost(filters *filter.Pipeline, library *media.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f := currentFeed(c)
+		c.Request = c.Request.WithContext(filter.WithFeed(c.Request.Context(), f.Name))
+		NewsfeedPost(f.Repo, filters, library)(c)
+	}
+}
+
+func FeedEngagementPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		EngagementPost(currentFeed(c).Repo)(c)
+	}
+}
+
+func FeedModerationGet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ModerationGet(currentFeed(c).Repo)(c)
+	}
+}
+
+func FeedModerationApprovePost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ModerationApprovePost(currentFeed(c).Repo)(c)
+	}
+}
+
+func FeedModerationRejectPost() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ModerationRejectPost(currentFeed(c).Repo)(c)
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"newsfeeder/platform/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader lets clients retry a request without repeating it.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// DefaultIdempotentBodyBytes is the default limit on the body of a request
+// with an Idempotency-Key, which is held in memory to be fingerprinted.
+const DefaultIdempotentBodyBytes = 1 << 20
+
+// Idempotency answers a retried request carrying the same Idempotency-Key
+// with the stored response of the first one. Reusing a key with a different
+// body gets 422, and retrying while the first request is still running gets
+// 409. Server errors and panics are not stored, so they can be retried.
+// Keys are scoped to the method, path and Authorization header, so the
+// same key sent to another route or by another client is a new request.
+// Bodies over maxBody bytes get 413. Requests without the header are
+// handled as usual.
+func Idempotency(store *idempotency.Store, maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			return
+		}
+		if len(key) > 255 {
+			abortWithError(c, http.StatusBadRequest, errors.New("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			abortWithError(c, http.StatusRequestEntityTooLarge,
+				fmt.Errorf("request body must be at most %d bytes", tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		key = scopedKey(c.Request, key)
+		sum := sha256.Sum256(body)
+		stored, err := store.Begin(key, hex.EncodeToString(sum[:]))
+		switch {
+		case errors.Is(err, idempotency.ErrMismatch):
+			abortWithError(c, http.StatusUnprocessableEntity, err)
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			abortWithError(c, http.StatusConflict, err)
+			return
+		case stored != nil:
+			replay(c, stored)
+			return
+		}
+
+		// Release the key unless a response is stored, including when a
+		// later handler panics.
+		completed := false
+		defer func() {
+			if !completed {
+				store.Abandon(key)
+			}
+		}()
+
+		w := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := w.Status()
+		if status >= 500 {
+			return
+		}
+		store.Complete(key, idempotency.Response{
+			Status: status,
+			Header: w.Header().Clone(),
+			Body:   w.body.Bytes(),
+		})
+		completed = true
+	}
+}
+
+// scopedKey qualifies a client's key with the route and credentials it was
+// sent with. The credentials are hashed rather than kept in memory.
+func scopedKey(r *http.Request, key string) string {
+	auth := sha256.Sum256([]byte(r.Header.Get("Authorization")))
+	return r.Method + " " + r.URL.Path + " " + hex.EncodeToString(auth[:8]) + " " + key
+}
+
+func replay(c *gin.Context, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		if name == RequestIDHeader {
+			continue
+		}
+		c.Writer.Header()[name] = values
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Writer.WriteHeader(resp.Status)
+	c.Writer.Write(resp.Body)
+	c.Abort()
+}
+
+// capturingWriter keeps a copy of the response body.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"newsfeeder/platform/idempotency"
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+func postWithKey(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/newsfeed", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New(newsfeed.WithModeration(true))
+	r := gin.New()
+	r.POST("/newsfeed", Idempotency(idempotency.NewStore(time.Hour, nil), DefaultIdempotentBodyBytes), NewsfeedPost(feed, nil, nil))
+
+	first := postWithKey(r, "abc", `{"title": "Hello"}`)
+	retry := postWithKey(r, "abc", `{"title": "Hello"}`)
+
+	if first.Code != http.StatusAccepted || retry.Code != first.Code {
+		t.Errorf("Got %d then %d, want 202 twice", first.Code, retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("Retry body %q differs from %q", retry.Body.String(), first.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("Retry was not marked as replayed")
+	}
+	if n := len(feed.Queue(context.Background(), "")); n != 1 {
+		t.Errorf("Feed holds %d items, want 1", n)
+	}
+}
+
+func TestIdempotencyRejectsDifferentBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	r := gin.New()
+	r.POST("/newsfeed", Idempotency(idempotency.NewStore(time.Hour, nil), DefaultIdempotentBodyBytes), NewsfeedPost(feed, nil, nil))
+
+	postWithKey(r, "abc", `{"title": "Hello"}`)
+	w := postWithKey(r, "abc", `{"title": "Goodbye"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Got %d, want 422", w.Code)
+	}
+	if n := len(feed.GetAll(context.Background())); n != 1 {
+		t.Errorf("Feed holds %d items, want 1", n)
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	r := gin.New()
+	r.POST("/newsfeed", Idempotency(idempotency.NewStore(time.Hour, nil), DefaultIdempotentBodyBytes), NewsfeedPost(feed, nil, nil))
+
+	postWithKey(r, "", `{"title": "Hello"}`)
+	postWithKey(r, "", `{"title": "Hello"}`)
+
+	if n := len(feed.GetAll(context.Background())); n != 2 {
+		t.Errorf("Feed holds %d items, want 2", n)
+	}
+}
+
+func TestIdempotencyLimitsBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	keys := idempotency.NewStore(time.Hour, nil)
+	r := gin.New()
+	r.POST("/newsfeed", Idempotency(keys, 32), NewsfeedPost(feed, nil, nil))
+
+	w := postWithKey(r, "k1", `{"title": "`+strings.Repeat("x", 64)+`"}`)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Got %d, want 413", w.Code)
+	}
+	if n := len(feed.GetAll(context.Background())); n != 0 || keys.Len() != 0 {
+		t.Errorf("Feed holds %d items and the store %d keys, want none", n, keys.Len())
+	}
+}
+
+func TestIdempotencyReleasesKeyAfterPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	panicked := false
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(ioutil.Discard))
+	r.POST("/newsfeed", Idempotency(idempotency.NewStore(time.Hour, nil), DefaultIdempotentBodyBytes), func(c *gin.Context) {
+		if !panicked {
+			panicked = true
+			panic("handler bug")
+		}
+	}, NewsfeedPost(feed, nil, nil))
+
+	first := postWithKey(r, "abc", `{"title": "Hello"}`)
+	retry := postWithKey(r, "abc", `{"title": "Hello"}`)
+
+	if first.Code != http.StatusInternalServerError || retry.Code != http.StatusNoContent {
+		t.Errorf("Got %d then %d, want 500 then 204", first.Code, retry.Code)
+	}
+}
+
+func TestIdempotencyKeysAreScopedToRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feeds := map[string]*newsfeed.Repo{"a": newsfeed.New(), "b": newsfeed.New()}
+	r := gin.New()
+	r.POST("/feeds/:feed/items", Idempotency(idempotency.NewStore(time.Hour, nil), DefaultIdempotentBodyBytes), func(c *gin.Context) {
+		NewsfeedPost(feeds[c.Param("feed")], nil, nil)(c)
+	})
+
+	for _, name := range []string{"a", "b"} {
+		req := httptest.NewRequest(http.MethodPost, "/feeds/"+name+"/items", strings.NewReader(`{"title": "Hello"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("POST to feed %s: got %d, replayed %q", name, w.Code, w.Header().Get("Idempotent-Replayed"))
+		}
+		if n := len(feeds[name].GetAll(context.Background())); n != 1 {
+			t.Errorf("Feed %s holds %d items, want 1", name, n)
+		}
+	}
+}
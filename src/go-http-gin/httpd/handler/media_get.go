@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"newsfeeder/platform/media"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaGet serves an uploaded file. Only images are shown inline; anything
+// else is offered as a download.
+func MediaGet(library *media.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		a, err := library.Get(ctx, c.Param("id"))
+		if err != nil {
+			abortWithError(c, mediaErrorStatus(err), err)
+			return
+		}
+		content, err := library.Open(ctx, a.ID)
+		if err != nil {
+			abortWithError(c, mediaErrorStatus(err), err)
+			return
+		}
+		defer content.Close()
+
+		disposition := "attachment"
+		if strings.HasPrefix(a.ContentType, "image/") {
+			disposition = "inline"
+		}
+		c.DataFromReader(http.StatusOK, a.Size, a.ContentType, content, map[string]string{
+			"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}),
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
+
+// MediaThumbnailGet serves the thumbnail generated for an image.
+func MediaThumbnailGet(library *media.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		a, err := library.Get(ctx, c.Param("id"))
+		if err != nil {
+			abortWithError(c, mediaErrorStatus(err), err)
+			return
+		}
+		content, err := library.OpenThumbnail(ctx, a.ID)
+		if err != nil {
+			abortWithError(c, mediaErrorStatus(err), err)
+			return
+		}
+		defer content.Close()
+
+		contentType := "image/png"
+		if a.ContentType == "image/jpeg" {
+			contentType = a.ContentType
+		}
+		c.DataFromReader(http.StatusOK, -1, contentType, content, map[string]string{
+			"X-Content-Type-Options": "nosniff",
+		})
+	}
+}
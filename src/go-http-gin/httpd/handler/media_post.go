@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"newsfeeder/platform/media"
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxMediaFiles bounds how many files one upload request may carry.
+const maxMediaFiles = 10
+
+// MediaPost stores every file part of a multipart/form-data request and
+// returns their attachments, ready to be referenced from POST /newsfeed.
+// Parts are streamed to the library one at a time rather than buffered by
+// the multipart parser.
+func MediaPost(library *media.Library) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, span := tracer().Start(c.Request.Context(), "MediaPost")
+		defer span.End()
+
+		reader, err := c.Request.MultipartReader()
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		attachments := []newsfeed.Attachment{}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				abortWithError(c, http.StatusBadRequest, err)
+				return
+			}
+			if part.FileName() == "" {
+				part.Close()
+				continue
+			}
+			if len(attachments) == maxMediaFiles {
+				abortWithError(c, http.StatusRequestEntityTooLarge, errors.New("too many files in one request"))
+				return
+			}
+
+			a, err := library.Save(ctx, part.FileName(), part)
+			part.Close()
+			if err != nil {
+				abortWithError(c, mediaErrorStatus(err), err)
+				return
+			}
+			attachments = append(attachments, a)
+		}
+
+		if len(attachments) == 0 {
+			abortWithError(c, http.StatusBadRequest, errors.New("no files in request"))
+			return
+		}
+		c.JSON(http.StatusCreated, attachments)
+	}
+}
+
+func mediaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, media.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, media.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationGet lists the moderation queue, pending items by default.
+// ?status=rejected lists rejected items and ?status=all lists both.
+func ModerationGet(mod newsfeed.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var status newsfeed.Status
+		switch c.DefaultQuery("status", "pending") {
+		case "pending":
+			status = newsfeed.StatusPending
+		case "rejected":
+			status = newsfeed.StatusRejected
+		case "all":
+		default:
+			abortWithError(c, http.StatusBadRequest, errors.New("status must be pending, rejected or all"))
+			return
+		}
+		c.JSON(http.StatusOK, mod.Queue(c.Request.Context(), status))
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+type moderationRejectRequest struct {
+	Note string `json:"note"`
+}
+
+func ModerationApprovePost(mod newsfeed.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		item, err := mod.Approve(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			moderationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func ModerationRejectPost(mod newsfeed.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestBody := moderationRejectRequest{}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&requestBody); err != nil {
+				abortWithError(c, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		item, err := mod.Reject(c.Request.Context(), c.Param("id"), requestBody.Note)
+		if err != nil {
+			moderationError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func moderationError(c *gin.Context, err error) {
+	if errors.Is(err, newsfeed.ErrNotFound) {
+		abortWithError(c, http.StatusNotFound, err)
+		return
+	}
+	abortWithError(c, http.StatusInternalServerError, err)
+}
@@ -1,14 +1,70 @@
 package handler
 
 import (
-	"newsfeeder/platform/newsfeed"
+	"errors"
 	"net/http"
+	"strconv"
+
+	"newsfeeder/platform/newsfeed"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// NewsfeedGet lists the feed, oldest first or, with ?sort=trending, by
+// trending score. A ?q= parameter restricts it to items whose title or post
+// contains the query, and ?limit= and ?offset= select a page. The
+// X-Total-Count header holds the number of items before paging.
 func NewsfeedGet(feed newsfeed.Getter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		results := feed.GetAll()
+		ctx, span := tracer().Start(c.Request.Context(), "NewsfeedGet")
+		defer span.End()
+
+		var results []newsfeed.Item
+		switch sort := c.Query("sort"); sort {
+		case "", "recent":
+			results = feed.GetAll(ctx)
+		case "trending":
+			results = feed.Trending(ctx)
+		default:
+			abortWithError(c, http.StatusBadRequest, errors.New("sort must be recent or trending"))
+			return
+		}
+		if q := c.Query("q"); q != "" {
+			results = newsfeed.Search(results, q)
+		}
+		span.SetAttributes(attribute.Int("newsfeed.items", len(results)))
+		c.Header("X-Total-Count", strconv.Itoa(len(results)))
+
+		offset, err := queryInt(c, "offset", 0)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		limit, err := queryInt(c, "limit", len(results))
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		if offset > len(results) {
+			offset = len(results)
+		}
+		results = results[offset:]
+		if limit < len(results) {
+			results = results[:limit]
+		}
 		c.JSON(http.StatusOK, results)
 	}
-}
\ No newline at end of file
+}
+
+func queryInt(c *gin.Context, name string, def int) (int, error) {
+	v, ok := c.GetQuery(name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New(name + " must be a non-negative integer")
+	}
+	return n, nil
+}
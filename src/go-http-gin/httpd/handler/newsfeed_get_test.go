@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewsfeedGetPaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	for i := 0; i < 3; i++ {
+		feed.Add(context.Background(), newsfeed.Item{Title: "Item " + strconv.Itoa(i)})
+	}
+	r := gin.New()
+	r.GET("/newsfeed", NewsfeedGet(feed))
+
+	for _, tt := range []struct {
+		query  string
+		titles []string
+	}{
+		{"", []string{"Item 0", "Item 1", "Item 2"}},
+		{"?offset=1&limit=1", []string{"Item 1"}},
+		{"?offset=1&limit=9223372036854775807", []string{"Item 1", "Item 2"}},
+		{"?offset=9223372036854775807&limit=9223372036854775807", []string{}},
+		{"?limit=0", []string{}},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/newsfeed"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d", tt.query, w.Code)
+			continue
+		}
+		var items []newsfeed.Item
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		titles := []string{}
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+		if len(titles) != len(tt.titles) {
+			t.Errorf("GET %s: got %v, want %v", tt.query, titles, tt.titles)
+			continue
+		}
+		for i := range titles {
+			if titles[i] != tt.titles[i] {
+				t.Errorf("GET %s: got %v, want %v", tt.query, titles, tt.titles)
+				break
+			}
+		}
+		if got := w.Header().Get("X-Total-Count"); got != "3" {
+			t.Errorf("GET %s: X-Total-Count = %s, want 3", tt.query, got)
+		}
+	}
+}
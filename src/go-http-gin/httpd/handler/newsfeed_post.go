@@ -1,27 +1,97 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
+
+	"newsfeeder/platform/filter"
+	"newsfeeder/platform/media"
 	"newsfeeder/platform/newsfeed"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type newsfeedPostRequest struct {
-	Title string `json:"title"`
-	Post string	`json:"post" `
+	Title     string     `json:"title"`
+	Post      string     `json:"post"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// TTL is a Go duration such as "90m", counted from publication.
+	TTL string `json:"ttl"`
+	// Attachments are IDs returned by POST /media.
+	Attachments []string `json:"attachments"`
 }
 
-func NewsfeedPost(feed newsfeed.Added) gin.HandlerFunc {
+// NewsfeedPost runs an item through the content filters and adds it. Items
+// held for moderation are returned with 202 Accepted so the poster can
+// follow up on them; rejected items get 422. A nil library disables
+// attachments.
+func NewsfeedPost(feed newsfeed.Added, filters *filter.Pipeline, library *media.Library) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, span := tracer().Start(c.Request.Context(), "NewsfeedPost")
+		defer span.End()
+
 		requestBody := newsfeedPostRequest{}
-		c.Bind(&requestBody)
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
 
-		item := newsfeed.Item {
+		item := newsfeed.Item{
 			Title: requestBody.Title,
-			Post: requestBody.Post,
+			Post:  requestBody.Post,
+		}
+		if requestBody.PublishAt != nil {
+			item.PublishAt = *requestBody.PublishAt
+		}
+
+		switch {
+		case requestBody.ExpiresAt != nil && requestBody.TTL != "":
+			abortWithError(c, http.StatusBadRequest, errors.New("expires_at and ttl are mutually exclusive"))
+			return
+		case requestBody.ExpiresAt != nil:
+			item.ExpiresAt = *requestBody.ExpiresAt
+		case requestBody.TTL != "":
+			ttl, err := time.ParseDuration(requestBody.TTL)
+			if err != nil || ttl <= 0 {
+				abortWithError(c, http.StatusBadRequest, errors.New("ttl must be a positive duration such as \"90m\""))
+				return
+			}
+			item.TTL = ttl
 		}
-		feed.Add(item)
 
+		if len(requestBody.Attachments) > 0 && library == nil {
+			abortWithError(c, http.StatusBadRequest, errors.New("attachments are not enabled"))
+			return
+		}
+		for _, id := range requestBody.Attachments {
+			a, err := library.Get(ctx, id)
+			if errors.Is(err, media.ErrNotFound) {
+				abortWithError(c, http.StatusBadRequest, fmt.Errorf("unknown attachment %q", id))
+				return
+			}
+			if err != nil {
+				abortWithError(c, http.StatusInternalServerError, err)
+				return
+			}
+			item.Attachments = append(item.Attachments, a)
+		}
+
+		if err := filters.Run(ctx, &item); err != nil {
+			abortWithError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		item = feed.Add(ctx, item)
+		span.SetAttributes(attribute.String("newsfeed.item_id", item.ID))
+
+		if item.Status == newsfeed.StatusPending {
+			c.JSON(http.StatusAccepted, item)
+			return
+		}
 		c.Status(http.StatusNoContent)
 	}
-}
\ No newline at end of file
+}
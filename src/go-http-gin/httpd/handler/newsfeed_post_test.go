@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewsfeedPostRejectsBadBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	r := gin.New()
+	r.POST("/newsfeed", NewsfeedPost(feed, nil, nil))
+
+	for _, body := range []string{
+		`{"title": "Launch", "publish_at": "tomorrow"}`,
+		`{"title": "Launch"`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/newsfeed", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: status %d, want 400", body, w.Code)
+		}
+	}
+	if items := feed.GetAll(context.Background()); len(items) != 0 {
+		t.Errorf("Feed holds %v after bad requests", items)
+	}
+	if s := feed.Scheduled(); len(s) != 0 {
+		t.Errorf("Feed scheduled %v after bad requests", s)
+	}
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationItemsGet returns the full replicable state of every feed,
+// keyed by feed name, for a peer to merge.
+func ReplicationItemsGet(feeds newsfeed.Replicated) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, feeds.Export(c.Request.Context()))
+	}
+}
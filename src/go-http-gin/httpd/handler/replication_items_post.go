@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationItemsPost merges items pushed by a peer, keyed by feed name.
+// Items for feeds that do not exist here are ignored.
+func ReplicationItemsPost(feeds newsfeed.Replicated) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var items map[string][]newsfeed.Item
+		if err := c.ShouldBindJSON(&items); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+		feeds.Merge(c.Request.Context(), items)
+		c.Status(http.StatusNoContent)
+	}
+}
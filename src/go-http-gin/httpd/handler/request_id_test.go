@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"newsfeeder/platform/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveRequestID sends a request with the given X-Request-ID through
+// RequestID and returns the response header and the ID the handler saw.
+func serveRequestID(t *testing.T, id string) (string, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Header().Get(RequestIDHeader), seen
+}
+
+func TestRequestIDEchoesIncomingID(t *testing.T) {
+	got, seen := serveRequestID(t, "client-id-42")
+	if got != "client-id-42" {
+		t.Errorf("Got response ID %q, want client-id-42", got)
+	}
+	if seen != "client-id-42" {
+		t.Errorf("Got context ID %q, want client-id-42", seen)
+	}
+}
+
+func TestRequestIDGeneratesMissingID(t *testing.T) {
+	got, seen := serveRequestID(t, "")
+	if len(got) != 32 {
+		t.Errorf("Got response ID %q, want 32 hex digits", got)
+	}
+	if seen != got {
+		t.Errorf("Got context ID %q, want %q", seen, got)
+	}
+	if other, _ := serveRequestID(t, ""); other == got {
+		t.Errorf("Got the same generated ID %q twice", got)
+	}
+}
+
+func TestRequestIDReplacesBadID(t *testing.T) {
+	for _, id := range []string{
+		"has space",
+		"tab\tinside",
+		"nön-ascii",
+		strings.Repeat("a", 129),
+	} {
+		got, seen := serveRequestID(t, id)
+		if got == id || len(got) != 32 {
+			t.Errorf("Incoming ID %q: got response ID %q, want a generated one", id, got)
+		}
+		if seen != got {
+			t.Errorf("Incoming ID %q: got context ID %q, want %q", id, seen, got)
+		}
+	}
+}
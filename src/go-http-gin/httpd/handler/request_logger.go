@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"log/slog"
+	"time"
+
+	"newsfeeder/platform/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestLogger puts logger in each request context, extended with the
+// request and trace IDs when RequestID and Tracing run first, and writes one
+// record per request.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx := c.Request.Context()
+		reqLogger := logger
+		if id := logging.RequestID(ctx); id != "" {
+			reqLogger = reqLogger.With("request_id", id)
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			reqLogger = reqLogger.With("trace_id", sc.TraceID().String())
+		}
+		ctx = logging.NewContext(ctx, reqLogger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		reqLogger.LogAttrs(ctx, level, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
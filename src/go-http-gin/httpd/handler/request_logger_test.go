@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"newsfeeder/platform/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLoggerRecordsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), RequestLogger(logger))
+	r.GET("/items/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handled")
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var records []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]interface{}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Got %d log records, want 2: %s", len(records), buf.String())
+	}
+	for _, rec := range records {
+		if rec["request_id"] != "abc-123" {
+			t.Errorf("Record %q has request_id %v, want abc-123", rec["msg"], rec["request_id"])
+		}
+	}
+
+	access := records[1]
+	if access["msg"] != "request" || access["level"] != "WARN" {
+		t.Errorf("Got %v %v, want a WARN request record", access["level"], access["msg"])
+	}
+	if access["route"] != "/items/:id" || access["path"] != "/items/7" || access["status"] != float64(404) {
+		t.Errorf("Got route %v, path %v, status %v", access["route"], access["path"], access["status"])
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireToken rejects requests that do not carry token as a bearer token.
+// An empty token lets every request through; the server only uses one when
+// ADMIN_OPEN is set.
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasToken(c, token) {
+			c.Header("WWW-Authenticate", "Bearer")
+			abortWithError(c, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+		}
+	}
+}
+
+// hasToken reports whether the request carries token as a bearer token, or
+// token is empty.
+func hasToken(c *gin.Context, token string) bool {
+	if token == "" {
+		return true
+	}
+	const scheme = "Bearer "
+	auth := c.GetHeader("Authorization")
+	if len(auth) < len(scheme) || !strings.EqualFold(auth[:len(scheme)], scheme) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth[len(scheme):]), []byte(token)) == 1
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tt := range []struct {
+		token, header string
+		want          int
+	}{
+		{"s3cret", "Bearer s3cret", http.StatusNoContent},
+		{"s3cret", "bearer s3cret", http.StatusNoContent},
+		{"s3cret", "s3cret", http.StatusUnauthorized},
+		{"s3cret", "Basic s3cret", http.StatusUnauthorized},
+		{"s3cret", "Bearer wrong", http.StatusUnauthorized},
+		{"s3cret", "", http.StatusUnauthorized},
+		{"", "", http.StatusNoContent},
+	} {
+		r := gin.New()
+		r.GET("/admin", RequireToken(tt.token), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Token %q with Authorization %q got %d, want %d", tt.token, tt.header, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookDelete(registry *webhook.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := registry.Remove(c.Param("id")); err != nil {
+			abortWithError(c, http.StatusNotFound, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
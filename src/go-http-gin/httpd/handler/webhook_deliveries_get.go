@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookDeliveriesGet(dispatcher *webhook.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, dispatcher.Deliveries())
+	}
+}
+
+func WebhookDeadLettersGet(dispatcher *webhook.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, dispatcher.DeadLetters())
+	}
+}
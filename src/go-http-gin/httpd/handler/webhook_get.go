@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookGet(registry *webhook.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subs := registry.List()
+		for i := range subs {
+			subs[i].Secret = ""
+		}
+		c.JSON(http.StatusOK, subs)
+	}
+}
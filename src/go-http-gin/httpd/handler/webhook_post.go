@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"newsfeeder/platform/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+type webhookPostRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// WebhookPost registers a subscription. The response is the only place the
+// secret is returned.
+func WebhookPost(registry *webhook.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestBody := webhookPostRequest{}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		sub, err := registry.Add(webhook.Subscription{
+			URL:    requestBody.URL,
+			Secret: requestBody.Secret,
+			Events: requestBody.Events,
+		})
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, sub)
+	}
+}
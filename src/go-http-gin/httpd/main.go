@@ -1,21 +1,303 @@
 package main
 
 import (
+	"context"
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
 	"newsfeeder/httpd/handler"
+	"newsfeeder/httpd/rpc"
+	"newsfeeder/platform/filter"
+	"newsfeeder/platform/idempotency"
+	"newsfeeder/platform/logging"
+	"newsfeeder/platform/media"
 	"newsfeeder/platform/newsfeed"
-	"fmt"
+	"newsfeeder/platform/replication"
+	"newsfeeder/platform/tracing"
+	"newsfeeder/platform/webhook"
+
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	fmt.Println("Hello World")
+	os.Exit(run())
+}
+
+// run starts the service and blocks until it fails or is stopped with
+// SIGINT or SIGTERM, then shuts it down. It returns the exit status.
+func run() int {
+	cfg, err := logging.ConfigFromEnv()
+	if err != nil {
+		slog.Error("logging config", "error", err)
+		return 1
+	}
+	logger := logging.New(cfg, os.Stdout)
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(tracing.ConfigFromEnv(), "newsfeeder")
+	if err != nil {
+		logger.Error("tracing setup", "error", err)
+		return 1
+	}
+	defer func() {
+		// Flush the spans still buffered by the batcher.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("tracing shutdown", "error", err)
+		}
+	}()
+
+	halfLife := newsfeed.DefaultHalfLife
+	if v := os.Getenv("TRENDING_HALF_LIFE"); v != "" {
+		halfLife, err = time.ParseDuration(v)
+		if err != nil || halfLife <= 0 {
+			logger.Error("TRENDING_HALF_LIFE must be a positive duration", "value", v)
+			return 1
+		}
+	}
+	feeds := newsfeed.NewRegistry(context.Background(), newsfeed.WithHalfLife(halfLife))
+	defaultFeed, err := feeds.Create(newsfeed.DefaultFeed, newsfeed.Settings{
+		Moderation: os.Getenv("NEWSFEED_MODERATION") == "true",
+	})
+	if err != nil {
+		logger.Error("default feed", "error", err)
+		return 1
+	}
+	feed := defaultFeed.Repo
+
+	expvar.Publish("newsfeed_janitor", expvar.Func(func() interface{} {
+		stats := map[string]newsfeed.JanitorStats{}
+		for _, f := range feeds.List() {
+			stats[f.Name] = f.Janitor.Stats()
+		}
+		return stats
+	}))
+
+	hooks := webhook.NewRegistry()
+	dispatcher := webhook.NewDispatcher(hooks)
+	defer dispatcher.Close()
+	// Stop feeding the dispatcher before it is closed.
+	published, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+	go func() {
+		for item := range published {
+			dispatcher.Publish(webhook.NewEvent(webhook.EventItemCreated, item))
+		}
+	}()
+
+	filters := filter.NewPipeline()
+	if path := os.Getenv("FILTERS_CONFIG"); path != "" {
+		cfg, err := filter.LoadConfig(path)
+		if err == nil {
+			filters, err = cfg.Build()
+		}
+		if err != nil {
+			logger.Error("content filters", "path", path, "error", err)
+			return 1
+		}
+	}
+
+	idempotencyWindow := 24 * time.Hour
+	if window := os.Getenv("IDEMPOTENCY_WINDOW"); window != "" {
+		idempotencyWindow, err = time.ParseDuration(window)
+		if err != nil {
+			logger.Error("IDEMPOTENCY_WINDOW", "error", err)
+			return 1
+		}
+	}
+	idempotencyKeys := idempotency.NewStore(idempotencyWindow, nil)
+	idempotentBodyBytes := int64(handler.DefaultIdempotentBodyBytes)
+	if maxBytes := os.Getenv("IDEMPOTENCY_MAX_BYTES"); maxBytes != "" {
+		idempotentBodyBytes, err = strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil || idempotentBodyBytes <= 0 {
+			logger.Error("IDEMPOTENCY_MAX_BYTES must be a positive number of bytes", "value", maxBytes)
+			return 1
+		}
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	storage, err := media.NewLocalDisk(mediaDir)
+	if err != nil {
+		logger.Error("media storage", "dir", mediaDir, "error", err)
+		return 1
+	}
+	limits := media.DefaultLimits
+	if maxBytes := os.Getenv("MEDIA_MAX_BYTES"); maxBytes != "" {
+		limits.MaxBytes, err = strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil || limits.MaxBytes <= 0 {
+			logger.Error("MEDIA_MAX_BYTES must be a positive number of bytes", "value", maxBytes)
+			return 1
+		}
+	}
+	library := media.NewLibrary(storage, limits, "/media/")
+	orphanTTL := 24 * time.Hour
+	if ttl := os.Getenv("MEDIA_ORPHAN_TTL"); ttl != "" {
+		orphanTTL, err = time.ParseDuration(ttl)
+		if err != nil || orphanTTL <= 0 {
+			logger.Error("MEDIA_ORPHAN_TTL must be a positive duration", "value", ttl)
+			return 1
+		}
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery(), handler.RequestID(), handler.Tracing(), handler.RequestLogger(logger))
 
-	feed := newsfeed.New()
-	r := gin.Default()
-	 
 	r.GET("/ping", handler.PingGet())
 	r.GET("/newsfeed", handler.NewsfeedGet(feed))
-	r.POST("/newsfeed", handler.NewsfeedPost(feed))
-	
-	r.Run() // listen and serve on 0.0.0.0:8080
+	r.POST("/newsfeed", handler.Idempotency(idempotencyKeys, idempotentBodyBytes), handler.NewsfeedPost(feed, filters, library))
+	r.POST("/newsfeed/:id/engagement", handler.EngagementPost(feed))
+	r.GET("/media/:id", handler.MediaGet(library))
+	r.GET("/media/:id/thumbnail", handler.MediaThumbnailGet(library))
+
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		if os.Getenv("ADMIN_OPEN") != "true" {
+			logger.Error("ADMIN_TOKEN is not set; set ADMIN_OPEN=true to leave the admin routes open")
+			return 1
+		}
+		logger.Warn("ADMIN_OPEN is set, admin routes are open")
+	}
+	admin := r.Group("/admin", handler.RequireToken(adminToken))
+	// Uploads cost disk space until pruned, so only the admin may make them.
+	r.POST("/media", handler.RequireToken(adminToken), handler.MediaPost(library))
+	admin.GET("/moderation", handler.ModerationGet(feed))
+	admin.POST("/moderation/:id/approve", handler.ModerationApprovePost(feed))
+	admin.POST("/moderation/:id/reject", handler.ModerationRejectPost(feed))
+	admin.GET("/webhooks", handler.WebhookGet(hooks))
+	admin.POST("/webhooks", handler.WebhookPost(hooks))
+	admin.DELETE("/webhooks/:id", handler.WebhookDelete(hooks))
+	admin.GET("/webhooks/deliveries", handler.WebhookDeliveriesGet(dispatcher))
+	admin.GET("/webhooks/dead-letters", handler.WebhookDeadLettersGet(dispatcher))
+
+	r.GET("/feeds", handler.FeedsGet(feeds, adminToken))
+	feedRoutes := r.Group("/feeds/:feed", handler.FeedAccess(feeds, adminToken))
+	feedRoutes.GET("", handler.FeedGet())
+	feedRoutes.GET("/items", handler.FeedItemsGet())
+	feedRoutes.POST("/items", handler.Idempotency(idempotencyKeys, idempotentBodyBytes), handler.FeedItemsPost(filters, library))
+	feedRoutes.POST("/items/:id/engagement", handler.FeedEngagementPost())
+
+	admin.POST("/feeds", handler.FeedPost(feeds))
+	admin.PUT("/feeds/:feed", handler.FeedPut(feeds))
+	admin.DELETE("/feeds/:feed", handler.FeedDelete(feeds))
+	adminFeed := admin.Group("/feeds/:feed", handler.FeedAccess(feeds, adminToken))
+	adminFeed.GET("/moderation", handler.FeedModerationGet())
+	adminFeed.POST("/moderation/:id/approve", handler.FeedModerationApprovePost())
+	adminFeed.POST("/moderation/:id/reject", handler.FeedModerationRejectPost())
+
+	admin.GET("/replication/items", handler.ReplicationItemsGet(feeds))
+	admin.POST("/replication/items", handler.ReplicationItemsPost(feeds))
+	if peers := os.Getenv("REPLICATION_PEERS"); peers != "" {
+		interval := 10 * time.Second
+		if v := os.Getenv("REPLICATION_INTERVAL"); v != "" {
+			interval, err = time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				logger.Error("REPLICATION_INTERVAL must be a positive duration", "value", v)
+				return 1
+			}
+		}
+		node := replication.NewNode(feeds, strings.Split(peers, ","),
+			replication.WithToken(adminToken), replication.WithInterval(interval))
+		go node.Run(context.Background())
+		expvar.Publish("replication", expvar.Func(func() interface{} {
+			return node.Stats()
+		}))
+	}
+
+	admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+
+	// A signal, or either server failing, stops both.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go pruneMedia(ctx, library, feeds, orphanTTL)
+	grpcErr := make(chan error, 1)
+	go func() {
+		grpcErr <- serveGRPC(ctx, feed, filters, adminToken)
+		stop()
+	}()
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("newsfeeder starting")
+	status := 0
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		logger.Error("http server stopped", "error", err)
+		status = 1
+	}
+	stop()
+	if err := <-grpcErr; err != nil {
+		logger.Error("grpc server stopped", "error", err)
+		status = 1
+	}
+	logger.Info("newsfeeder stopped")
+	return status
+}
+
+// pruneMedia deletes, every hour until ctx is done, the uploads that no
+// item references once they are older than ttl.
+func pruneMedia(ctx context.Context, library *media.Library, feeds *newsfeed.Registry, ttl time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			inUse := map[string]bool{}
+			for _, items := range feeds.Export(ctx) {
+				for _, item := range items {
+					for _, a := range item.Attachments {
+						inUse[a.ID] = true
+					}
+				}
+			}
+			pruned, err := library.Prune(ctx, now.Add(-ttl), func(id string) bool { return inUse[id] })
+			if err != nil {
+				slog.Error("media prune", "error", err)
+			}
+			if pruned > 0 {
+				slog.Info("media pruned", "files", pruned)
+			}
+		}
+	}
+}
+
+// serveGRPC serves the gRPC API on $GRPC_PORT, 9090 by default, until ctx
+// is done. Posting needs adminToken, as the admin HTTP routes do.
+func serveGRPC(ctx context.Context, feed *newsfeed.Repo, filters *filter.Pipeline, adminToken string) error {
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = "9090"
+	}
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer(grpc.UnaryInterceptor(rpc.RequireToken(adminToken)))
+	rpc.NewServer(feed, feed, feed, filters).Register(s)
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	slog.Info("grpc serving", "addr", lis.Addr().String())
+	return s.Serve(lis)
 }
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	pb "newsfeeder/platform/newsfeed/newsfeedpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeMethods change the feed and need the token; reads are as open as
+// GET /newsfeed.
+var writeMethods = map[string]bool{
+	pb.Newsfeed_AddItem_FullMethodName: true,
+}
+
+// RequireToken rejects calls to methods that change the feed unless they
+// carry token as a bearer token in their authorization metadata. An empty
+// token lets every call through, like the HTTP admin routes with
+// ADMIN_OPEN set.
+func RequireToken(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if writeMethods[info.FullMethod] && !hasToken(ctx, token) {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func hasToken(ctx context.Context, token string) bool {
+	if token == "" {
+		return true
+	}
+	const scheme = "Bearer "
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if len(auth) >= len(scheme) && strings.EqualFold(auth[:len(scheme)], scheme) &&
+			subtle.ConstantTimeCompare([]byte(auth[len(scheme):]), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"newsfeeder/platform/filter"
+	"newsfeeder/platform/newsfeed"
+	pb "newsfeeder/platform/newsfeed/newsfeedpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Server implements the Newsfeed gRPC service on top of a feed.
+type Server struct {
+	pb.UnimplementedNewsfeedServer
+
+	getter     newsfeed.Getter
+	adder      newsfeed.Added
+	subscriber newsfeed.Subscriber
+	filters    *filter.Pipeline
+}
+
+// NewServer returns the service for a feed. filters may be nil.
+func NewServer(getter newsfeed.Getter, adder newsfeed.Added, subscriber newsfeed.Subscriber, filters *filter.Pipeline) *Server {
+	return &Server{
+		getter:     getter,
+		adder:      adder,
+		subscriber: subscriber,
+		filters:    filters,
+	}
+}
+
+// Register adds the service to s.
+func (srv *Server) Register(s *grpc.Server) {
+	pb.RegisterNewsfeedServer(s, srv)
+}
+
+func (srv *Server) AddItem(ctx context.Context, req *pb.AddItemRequest) (*pb.Item, error) {
+	item := newsfeed.Item{
+		Title: req.GetTitle(),
+		Post:  req.GetPost(),
+	}
+	if req.PublishAt != nil {
+		item.PublishAt = req.PublishAt.AsTime()
+	}
+
+	switch {
+	case req.ExpiresAt != nil && req.Ttl != nil:
+		return nil, status.Error(codes.InvalidArgument, "expires_at and ttl are mutually exclusive")
+	case req.ExpiresAt != nil:
+		item.ExpiresAt = req.ExpiresAt.AsTime()
+	case req.Ttl != nil:
+		ttl := req.Ttl.AsDuration()
+		if ttl <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "ttl must be positive")
+		}
+		item.TTL = ttl
+	}
+
+	if err := srv.filters.Run(ctx, &item); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return toProto(srv.adder.Add(ctx, item)), nil
+}
+
+// ListItems pages through the feed. Page tokens are offsets into the feed,
+// so pages can shift if items expire between calls.
+func (srv *Server) ListItems(ctx context.Context, req *pb.ListItemsRequest) (*pb.ListItemsResponse, error) {
+	size := int(req.GetPageSize())
+	switch {
+	case size < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+
+	offset := 0
+	if token := req.GetPageToken(); token != "" {
+		var err error
+		offset, err = strconv.Atoi(token)
+		if err != nil || offset < 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	items := srv.getter.GetAll(ctx)
+	if offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + size
+	if end > len(items) {
+		end = len(items)
+	}
+
+	resp := &pb.ListItemsResponse{}
+	for _, item := range items[offset:end] {
+		resp.Items = append(resp.Items, toProto(item))
+	}
+	if end < len(items) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+// StreamItems sends items as they are published, after the current feed if
+// the request asks for a backfill. Like any feed subscriber, a stream that
+// reads too slowly misses items rather than holding up the feed; clients
+// that must not miss any should compare against ListItems.
+func (srv *Server) StreamItems(req *pb.StreamItemsRequest, stream grpc.ServerStreamingServer[pb.Item]) error {
+	// Subscribe before backfilling so nothing published in between is lost.
+	// Such items are both in the backfill and on the subscription, so the
+	// backfilled IDs are skipped when they come round again.
+	ctx := stream.Context()
+	items, cancel := srv.subscriber.Subscribe()
+	defer cancel()
+
+	sent := map[string]bool{}
+	if req.GetBackfill() {
+		for _, item := range srv.getter.GetAll(ctx) {
+			if err := stream.Send(toProto(item)); err != nil {
+				return err
+			}
+			sent[item.ID] = true
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item, ok := <-items:
+			if !ok {
+				return nil
+			}
+			if sent[item.ID] {
+				delete(sent, item.ID)
+				continue
+			}
+			if err := stream.Send(toProto(item)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProto(item newsfeed.Item) *pb.Item {
+	return &pb.Item{
+		Id:        item.ID,
+		Title:     item.Title,
+		Post:      item.Post,
+		PublishAt: timestamp(item.PublishAt),
+		ExpiresAt: timestamp(item.ExpiresAt),
+		CreatedAt: timestamp(item.CreatedAt),
+		Status:    string(item.Status),
+	}
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,217 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+	pb "newsfeeder/platform/newsfeed/newsfeedpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dial serves feed over an in-process listener and returns a client for it.
+func dial(t *testing.T, feed *newsfeed.Repo) pb.NewsfeedClient {
+	t.Helper()
+	return dialServer(t, NewServer(feed, feed, feed, nil))
+}
+
+func dialServer(t *testing.T, srv *Server, opts ...grpc.ServerOption) pb.NewsfeedClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(opts...)
+	srv.Register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewNewsfeedClient(conn)
+}
+
+func TestAddItem(t *testing.T) {
+	feed := newsfeed.New()
+	client := dial(t, feed)
+
+	item, err := client.AddItem(context.Background(), &pb.AddItemRequest{
+		Title: "Hello",
+		Post:  "From gRPC",
+		Ttl:   durationpb.New(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.GetId() == "" || item.GetExpiresAt() == nil {
+		t.Errorf("AddItem() = %v, want an ID and expiry", item)
+	}
+	if results := feed.GetAll(context.Background()); len(results) != 1 || results[0].Title != "Hello" {
+		t.Errorf("Item was not added to the feed: %v", results)
+	}
+}
+
+func TestAddItemRejectsExpiresAtAndTTL(t *testing.T) {
+	client := dial(t, newsfeed.New())
+
+	_, err := client.AddItem(context.Background(), &pb.AddItemRequest{
+		Title:     "Hello",
+		ExpiresAt: timestamppb.Now(),
+		Ttl:       durationpb.New(time.Hour),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddItem() error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestListItemsPages(t *testing.T) {
+	feed := newsfeed.New()
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		feed.Add(context.Background(), newsfeed.Item{Title: title})
+	}
+	client := dial(t, feed)
+
+	var titles []string
+	req := &pb.ListItemsRequest{PageSize: 2}
+	pages := 0
+	for {
+		resp, err := client.ListItems(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, item := range resp.GetItems() {
+			titles = append(titles, item.GetTitle())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+
+	if pages != 3 || len(titles) != 5 || titles[0] != "a" || titles[4] != "e" {
+		t.Errorf("Got %d pages of %v, want 3 pages of a..e", pages, titles)
+	}
+}
+
+func TestListItemsRejectsBadToken(t *testing.T) {
+	client := dial(t, newsfeed.New())
+
+	_, err := client.ListItems(context.Background(), &pb.ListItemsRequest{PageToken: "nope"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ListItems() error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestStreamItems(t *testing.T) {
+	feed := newsfeed.New()
+	feed.Add(context.Background(), newsfeed.Item{Title: "Existing"})
+	client := dial(t, feed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamItems(ctx, &pb.StreamItemsRequest{Backfill: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetTitle() != "Existing" {
+		t.Errorf("Backfill sent %q, want Existing", first.GetTitle())
+	}
+
+	// The subscription is in place once the backfill has arrived.
+	feed.Add(context.Background(), newsfeed.Item{Title: "Live"})
+	live, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.GetTitle() != "Live" {
+		t.Errorf("Stream sent %q, want Live", live.GetTitle())
+	}
+}
+
+// racingSubscriber publishes an item just after each subscription starts,
+// before the stream reads the backfill.
+type racingSubscriber struct {
+	*newsfeed.Repo
+}
+
+func (r racingSubscriber) Subscribe() (<-chan newsfeed.Item, func()) {
+	items, cancel := r.Repo.Subscribe()
+	r.Add(context.Background(), newsfeed.Item{Title: "Racing"})
+	return items, cancel
+}
+
+func TestStreamItemsSendsRacingItemOnce(t *testing.T) {
+	feed := newsfeed.New()
+	feed.Add(context.Background(), newsfeed.Item{Title: "Existing"})
+	client := dialServer(t, NewServer(feed, feed, racingSubscriber{feed}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamItems(ctx, &pb.StreamItemsRequest{Backfill: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	for len(titles) < 3 {
+		if len(titles) == 2 {
+			feed.Add(context.Background(), newsfeed.Item{Title: "Live"})
+		}
+		item, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, item.GetTitle())
+	}
+	if titles[0] != "Existing" || titles[1] != "Racing" || titles[2] != "Live" {
+		t.Errorf("Stream sent %v, want Existing, Racing, Live", titles)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	feed := newsfeed.New()
+	client := dialServer(t, NewServer(feed, feed, feed, nil), grpc.UnaryInterceptor(RequireToken("s3cret")))
+	req := &pb.AddItemRequest{Title: "Hello", Post: "From gRPC"}
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret"} {
+		ctx := context.Background()
+		if auth != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", auth)
+		}
+		if _, err := client.AddItem(ctx, req); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("AddItem with authorization %q: got %v, want Unauthenticated", auth, err)
+		}
+	}
+	if n := len(feed.GetAll(context.Background())); n != 0 {
+		t.Fatalf("Got %d items after rejected calls, want 0", n)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "bearer s3cret")
+	if _, err := client.AddItem(ctx, req); err != nil {
+		t.Errorf("AddItem with the token: %v", err)
+	}
+	if _, err := client.ListItems(context.Background(), &pb.ListItemsRequest{}); err != nil {
+		t.Errorf("ListItems without a token: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+	"newsfeeder/platform/newsfeed/client"
+)
+
+// fileList collects a repeatable -f flag.
+type fileList []string
+
+func (f *fileList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *fileList) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+func postCommand(e *env, args []string) error {
+	flags := flag.NewFlagSet("post", flag.ContinueOnError)
+	title := flags.String("title", "", "item title")
+	publishAt := flags.String("publish-at", "", "RFC 3339 time to publish the item at")
+	expiresAt := flags.String("expires-at", "", "RFC 3339 time the item expires at")
+	ttl := flags.String("ttl", "", "how long the item stays in the feed, e.g. 2h")
+	var files fileList
+	flags.Var(&files, "f", "JSON file holding an item or a list of items, - for stdin (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: newsfeedctl post [flags] [text...]\n\n"+
+			"The post text is taken from the arguments, or from stdin if there are none.")
+		flags.PrintDefaults()
+	}
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+
+	if len(files) > 0 {
+		if flags.NArg() > 0 {
+			return usageError("post: -f cannot be combined with text arguments")
+		}
+		for _, name := range files {
+			reqs, err := readItemFile(e, name)
+			if err != nil {
+				return err
+			}
+			for _, req := range reqs {
+				if err := e.api.AddItem(e.ctx, req); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	req := client.AddItemRequest{
+		Title: *title,
+		Post:  strings.Join(flags.Args(), " "),
+		TTL:   *ttl,
+	}
+	if flags.NArg() == 0 {
+		content, err := ioutil.ReadAll(e.stdin)
+		if err != nil {
+			return err
+		}
+		req.Post = strings.TrimRight(string(content), "\n")
+	}
+	var err error
+	if req.PublishAt, err = parseTime("publish-at", *publishAt); err != nil {
+		return err
+	}
+	if req.ExpiresAt, err = parseTime("expires-at", *expiresAt); err != nil {
+		return err
+	}
+	return e.api.AddItem(e.ctx, req)
+}
+
+// readItemFile decodes a single item or a list of items.
+func readItemFile(e *env, name string) ([]client.AddItemRequest, error) {
+	var r io.Reader = e.stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var reqs []client.AddItemRequest
+	if err := json.Unmarshal(content, &reqs); err == nil {
+		return reqs, nil
+	}
+	var req client.AddItemRequest
+	if err := json.Unmarshal(content, &req); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return []client.AddItemRequest{req}, nil
+}
+
+func parseTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("-%s: %v", name, err))
+	}
+	return &t, nil
+}
+
+func listCommand(e *env, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table, json or yaml")
+	sort := flags.String("sort", "recent", "order: recent or trending")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *sort != "recent" && *sort != "trending" {
+		return usageError(fmt.Sprintf("list: unknown order %q, want recent or trending", *sort))
+	}
+
+	items, _, err := e.api.ListItems(e.ctx, client.ListOptions{Sort: *sort})
+	if err != nil {
+		return err
+	}
+	return writeItems(e.stdout, *format, items)
+}
+
+func searchCommand(e *env, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table, json or yaml")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	q := strings.Join(flags.Args(), " ")
+	if q == "" {
+		return usageError("search: missing query")
+	}
+
+	items, _, err := e.api.ListItems(e.ctx, client.ListOptions{Query: q})
+	if err != nil {
+		return err
+	}
+	return writeItems(e.stdout, *format, items)
+}
+
+// tailCommand polls the feed and prints items it has not seen before.
+func tailCommand(e *env, args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	format := flags.String("o", "table", "output format: table, json or yaml")
+	backlog := flags.Int("n", 10, "number of existing items to print first")
+	interval := flags.Duration("interval", 2*time.Second, "how often to poll the server")
+	max := flags.Int("max", 0, "exit after printing this many new items (0 runs forever)")
+	if err := parseFlags(e, flags, args); err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *backlog < 0 {
+		return usageError("tail: -n must not be negative")
+	}
+
+	items, _, err := e.api.ListItems(e.ctx, client.ListOptions{})
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, item := range items {
+		seen[item.ID] = true
+	}
+	if *backlog < len(items) {
+		items = items[len(items)-*backlog:]
+	}
+	if err := streamItems(e.stdout, *format, items, true); err != nil {
+		return err
+	}
+
+	printed := 0
+	for *max == 0 || printed < *max {
+		time.Sleep(*interval)
+
+		items, _, err := e.api.ListItems(e.ctx, client.ListOptions{})
+		if err != nil {
+			return err
+		}
+		var fresh []newsfeed.Item
+		for _, item := range items {
+			if !seen[item.ID] {
+				seen[item.ID] = true
+				fresh = append(fresh, item)
+			}
+		}
+		if *max > 0 && printed+len(fresh) > *max {
+			fresh = fresh[:*max-printed]
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		if err := streamItems(e.stdout, *format, fresh, false); err != nil {
+			return err
+		}
+		printed += len(fresh)
+	}
+	return nil
+}
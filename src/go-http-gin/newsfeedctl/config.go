@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// config is read from a YAML file such as
+//
+//	server: http://localhost:8080
+//	token: s3cret
+//
+// and overridden by $NEWSFEEDCTL_SERVER and $NEWSFEEDCTL_TOKEN.
+type config struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "newsfeedctl", "config.yaml")
+}
+
+// loadConfig reads path if it exists and applies the environment on top.
+func loadConfig(path string) (config, error) {
+	cfg := config{Server: "http://localhost:8080"}
+
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return cfg, err
+		default:
+			if err := yaml.Unmarshal(content, &cfg); err != nil {
+				return cfg, err
+			}
+		}
+	}
+
+	if server := os.Getenv("NEWSFEEDCTL_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	if token := os.Getenv("NEWSFEEDCTL_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+	return cfg, nil
+}
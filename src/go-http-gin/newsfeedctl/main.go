@@ -0,0 +1,123 @@
+// Command newsfeedctl posts to and reads from a newsfeeder server.
+//
+//	newsfeedctl [-server URL] [-token TOKEN] [-config FILE] <command> [flags]
+//
+// Commands:
+//
+//	post    add items from arguments, stdin or JSON files
+//	list    print the feed
+//	tail    print items as they are published
+//	search  print items matching a query
+//
+// newsfeedctl exits with status 2 when it is run with bad flags or
+// arguments and with status 1 when a request or input file fails.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"newsfeeder/platform/newsfeed/client"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// env is what a command needs to talk to the server and the terminal.
+type env struct {
+	ctx    context.Context
+	api    *client.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands = map[string]func(e *env, args []string) error{
+	"post":   postCommand,
+	"list":   listCommand,
+	"tail":   tailCommand,
+	"search": searchCommand,
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("newsfeedctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", defaultConfigPath(), "config file")
+	server := flags.String("server", "", "server address (overrides config and $NEWSFEEDCTL_SERVER)")
+	token := flags.String("token", "", "API token (overrides config and $NEWSFEEDCTL_TOKEN)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: newsfeedctl [flags] post|list|tail|search [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "newsfeedctl: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "newsfeedctl:", err)
+		return 1
+	}
+	if *server != "" {
+		cfg.Server = *server
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+
+	e := &env{
+		ctx:    context.Background(),
+		api:    client.New(cfg.Server, client.WithToken(cfg.Token)),
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	if err := cmd(e, flags.Args()[1:]); err != nil {
+		if errors.Is(err, errFlags) {
+			return 2
+		}
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintln(stderr, "newsfeedctl:", err)
+			return 2
+		}
+		fmt.Fprintln(stderr, "newsfeedctl:", err)
+		return 1
+	}
+	return 0
+}
+
+// usageError is returned by commands for arguments they cannot run with;
+// like flag errors, it makes newsfeedctl exit with status 2.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// errFlags is returned by parseFlags; the flag package has already printed
+// the problem and the command's usage.
+var errFlags = errors.New("bad flags")
+
+// parseFlags parses a command's flags, reporting errors to stderr.
+func parseFlags(e *env, flags *flag.FlagSet, args []string) error {
+	flags.SetOutput(e.stderr)
+	if err := flags.Parse(args); err != nil {
+		return errFlags
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"newsfeeder/httpd/handler"
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+)
+
+// syncBuffer lets tail write while the test reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newServer runs the real newsfeed handlers and records the Authorization
+// header of the last request.
+func newServer(t *testing.T) (*httptest.Server, *newsfeed.Repo, *string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New()
+	var auth string
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		auth = c.GetHeader("Authorization")
+	})
+	r.GET("/newsfeed", handler.NewsfeedGet(feed))
+	r.POST("/newsfeed", handler.NewsfeedPost(feed, nil, nil))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, feed, &auth
+}
+
+func ctl(t *testing.T, srv *httptest.Server, stdin string, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-config", "", "-server", srv.URL}, args...)
+	if code := run(args, strings.NewReader(stdin), &stdout, &stderr); code != 0 {
+		t.Fatalf("newsfeedctl %v exited %d: %s", args, code, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestPostFromArgs(t *testing.T) {
+	srv, feed, auth := newServer(t)
+
+	ctl(t, srv, "", "-token", "s3cret", "post", "-title", "Hello", "-ttl", "1h", "from", "the", "cli")
+
+	items := feed.GetAll(context.Background())
+	if len(items) != 1 || items[0].Title != "Hello" || items[0].Post != "from the cli" {
+		t.Fatalf("Feed holds %v", items)
+	}
+	if items[0].ExpiresAt.IsZero() {
+		t.Errorf("ttl was not sent")
+	}
+	if *auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want the token", *auth)
+	}
+}
+
+func TestPostFromStdin(t *testing.T) {
+	srv, feed, _ := newServer(t)
+
+	ctl(t, srv, "piped body\n", "post", "-title", "Piped")
+
+	if items := feed.GetAll(context.Background()); len(items) != 1 || items[0].Post != "piped body" {
+		t.Errorf("Feed holds %v", items)
+	}
+}
+
+func TestPostFromFiles(t *testing.T) {
+	srv, feed, _ := newServer(t)
+	dir := t.TempDir()
+	one := filepath.Join(dir, "one.json")
+	many := filepath.Join(dir, "many.json")
+	ioutil.WriteFile(one, []byte(`{"title": "One"}`), 0644)
+	ioutil.WriteFile(many, []byte(`[{"title": "Two"}, {"title": "Three"}]`), 0644)
+
+	ctl(t, srv, `{"title": "Four"}`, "post", "-f", one, "-f", many, "-f", "-")
+
+	if items := feed.GetAll(context.Background()); len(items) != 4 || items[3].Title != "Four" {
+		t.Errorf("Feed holds %v", items)
+	}
+}
+
+func TestListFormats(t *testing.T) {
+	srv, feed, _ := newServer(t)
+	feed.Add(context.Background(), newsfeed.Item{Title: "Hello", Post: "World"})
+
+	table := ctl(t, srv, "", "list")
+	if !strings.HasPrefix(table, "ID") || !strings.Contains(table, "Hello") {
+		t.Errorf("Table output:\n%s", table)
+	}
+
+	var fromJSON []newsfeed.Item
+	if err := json.Unmarshal([]byte(ctl(t, srv, "", "list", "-o", "json")), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromJSON) != 1 || fromJSON[0].Title != "Hello" {
+		t.Errorf("JSON output decoded to %v", fromJSON)
+	}
+
+	var fromYAML []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(ctl(t, srv, "", "list", "-o", "yaml")), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if len(fromYAML) != 1 || fromYAML[0]["title"] != "Hello" {
+		t.Errorf("YAML output decoded to %v", fromYAML)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv, feed, _ := newServer(t)
+	feed.Add(context.Background(), newsfeed.Item{Title: "Release", Post: "v2 is out"})
+	feed.Add(context.Background(), newsfeed.Item{Title: "Outage", Post: "Login is down"})
+
+	out := ctl(t, srv, "", "search", "-o", "json", "login")
+
+	var items []newsfeed.Item
+	if err := json.Unmarshal([]byte(out), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Title != "Outage" {
+		t.Errorf("search returned %v", items)
+	}
+}
+
+func TestTail(t *testing.T) {
+	srv, feed, _ := newServer(t)
+	feed.Add(context.Background(), newsfeed.Item{Title: "Old"})
+
+	// listed is signalled once tail has read the feed it starts from.
+	listed := make(chan struct{}, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.Config.Handler.ServeHTTP(w, r)
+		select {
+		case listed <- struct{}{}:
+		default:
+		}
+	}))
+	t.Cleanup(proxy.Close)
+
+	stdout := &syncBuffer{}
+	done := make(chan int)
+	go func() {
+		args := []string{"-config", "", "-server", proxy.URL,
+			"tail", "-o", "json", "-n", "0", "-interval", "10ms", "-max", "1"}
+		done <- run(args, strings.NewReader(""), stdout, ioutil.Discard)
+	}()
+
+	select {
+	case <-listed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail did not list the feed")
+	}
+	feed.Add(context.Background(), newsfeed.Item{Title: "New"})
+
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("tail exited %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tail did not exit after -max items")
+	}
+
+	var item newsfeed.Item
+	if err := json.Unmarshal([]byte(stdout.String()), &item); err != nil {
+		t.Fatalf("%v in %q", err, stdout.String())
+	}
+	if item.Title != "New" {
+		t.Errorf("tail printed %q, want New", item.Title)
+	}
+}
+
+func TestConfigFileAndEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	ioutil.WriteFile(path, []byte("server: http://file:8080\ntoken: from-file\n"), 0644)
+	t.Setenv("NEWSFEEDCTL_TOKEN", "from-env")
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server != "http://file:8080" || cfg.Token != "from-env" {
+		t.Errorf("loadConfig() = %+v", cfg)
+	}
+}
+
+func TestServerErrorsAreReported(t *testing.T) {
+	srv, _, _ := newServer(t)
+	var stderr bytes.Buffer
+
+	code := run([]string{"-config", "", "-server", srv.URL, "post", "-title", "x", "-ttl", "soon", "body"},
+		strings.NewReader(""), ioutil.Discard, &stderr)
+
+	if code != 1 || !strings.Contains(stderr.String(), "ttl must be a positive duration") {
+		t.Errorf("exit %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestRequestFailuresExitOne(t *testing.T) {
+	srv, _, _ := newServer(t)
+	srv.Close()
+	for _, args := range [][]string{
+		{"-server", srv.URL, "list"},
+		{"-server", srv.URL, "post", "-f", filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		var stderr bytes.Buffer
+		code := run(append([]string{"-config", ""}, args...), strings.NewReader(""), ioutil.Discard, &stderr)
+		if code != 1 || stderr.Len() == 0 {
+			t.Errorf("newsfeedctl %v: exit %d, stderr %q", args, code, stderr.String())
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	srv, _, _ := newServer(t)
+	for _, args := range [][]string{
+		{"tail", "-n", "-1"},
+		{"search"},
+		{"list", "-bogus"},
+		{"list", "-o", "xml"},
+		{"list", "-sort", "oldest"},
+		{"post", "-publish-at", "tomorrow", "body"},
+		{"post", "-f", "items.json", "body"},
+	} {
+		var stderr bytes.Buffer
+		code := run(append([]string{"-config", "", "-server", srv.URL}, args...),
+			strings.NewReader(""), ioutil.Discard, &stderr)
+		if code != 2 || stderr.Len() == 0 {
+			t.Errorf("newsfeedctl %v: exit %d, stderr %q", args, code, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+
+	"gopkg.in/yaml.v2"
+)
+
+var formats = []string{"table", "json", "yaml"}
+
+func checkFormat(format string) error {
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return usageError(fmt.Sprintf("unknown output format %q, want one of %s", format, strings.Join(formats, ", ")))
+}
+
+// writeItems prints items in the given format.
+func writeItems(w io.Writer, format string, items []newsfeed.Item) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case "yaml":
+		return writeYAML(w, items)
+	default:
+		return writeTable(w, items, true)
+	}
+}
+
+// streamItems prints items as they arrive in tail: table rows without
+// repeating the header, one JSON object per line, or one YAML document per
+// item.
+func streamItems(w io.Writer, format string, items []newsfeed.Item, first bool) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml":
+		for _, item := range items {
+			fmt.Fprintln(w, "---")
+			if err := writeYAML(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return writeTable(w, items, first)
+	}
+}
+
+func writeTable(w io.Writer, items []newsfeed.Item, header bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if header {
+		fmt.Fprintln(tw, "ID\tCREATED\tTITLE\tPOST")
+	}
+	for _, item := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			item.ID,
+			item.CreatedAt.Local().Format(time.RFC3339),
+			truncate(item.Title, 30),
+			truncate(item.Post, 50),
+		)
+	}
+	return tw.Flush()
+}
+
+// writeYAML goes through JSON so the keys match the API's field names.
+func writeYAML(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(content, &generic); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config enables and tunes the built-in filters. Filters whose section is
+// missing are left out. Each action may be modify (profanity only), flag or
+// reject; empty picks the filter's default.
+//
+//	profanity:
+//	  words: [darn, heck]
+//	link_blocklist:
+//	  domains: [spam.example]
+//	max_links:
+//	  max: 3
+//	  action: flag
+//	duplicates:
+//	  window: 1h
+//	  max_entries: 100000
+type Config struct {
+	Profanity     *ProfanityConfig     `yaml:"profanity"`
+	LinkBlocklist *LinkBlocklistConfig `yaml:"link_blocklist"`
+	MaxLinks      *MaxLinksConfig      `yaml:"max_links"`
+	Duplicates    *DuplicatesConfig    `yaml:"duplicates"`
+}
+
+type ProfanityConfig struct {
+	Words  []string `yaml:"words"`
+	Action string   `yaml:"action"`
+}
+
+type LinkBlocklistConfig struct {
+	Domains []string `yaml:"domains"`
+	Action  string   `yaml:"action"`
+}
+
+type MaxLinksConfig struct {
+	Max    int    `yaml:"max"`
+	Action string `yaml:"action"`
+}
+
+type DuplicatesConfig struct {
+	// Window is a Go duration such as "1h".
+	Window string `yaml:"window"`
+	Action string `yaml:"action"`
+	// MaxEntries caps the posts remembered; 0 means DefaultMaxDuplicates.
+	MaxEntries int `yaml:"max_entries"`
+}
+
+// LoadConfig reads a YAML config file.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = yaml.UnmarshalStrict(content, &cfg)
+	return cfg, err
+}
+
+// Build returns a pipeline running the configured filters in the order
+// profanity, link blocklist, max links, duplicates.
+func (cfg Config) Build() (*Pipeline, error) {
+	p := NewPipeline()
+	if c := cfg.Profanity; c != nil {
+		if err := checkAction("profanity", c.Action, Modify, Flag, Reject); err != nil {
+			return nil, err
+		}
+		if len(c.Words) > 0 {
+			p.Register(NewProfanity(c.Words, c.Action))
+		}
+	}
+	if c := cfg.LinkBlocklist; c != nil {
+		if err := checkAction("link_blocklist", c.Action, Flag, Reject); err != nil {
+			return nil, err
+		}
+		p.Register(NewLinkBlocklist(c.Domains, c.Action))
+	}
+	if c := cfg.MaxLinks; c != nil {
+		if err := checkAction("max_links", c.Action, Flag, Reject); err != nil {
+			return nil, err
+		}
+		p.Register(NewMaxLinks(c.Max, c.Action))
+	}
+	if c := cfg.Duplicates; c != nil {
+		if err := checkAction("duplicates", c.Action, Flag, Reject); err != nil {
+			return nil, err
+		}
+		window, err := time.ParseDuration(c.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("duplicates: window must be a positive duration such as \"1h\"")
+		}
+		if c.MaxEntries < 0 {
+			return nil, fmt.Errorf("duplicates: max_entries must not be negative")
+		}
+		var opts []DuplicateOption
+		if c.MaxEntries > 0 {
+			opts = append(opts, WithMaxEntries(c.MaxEntries))
+		}
+		p.Register(NewDuplicate(window, c.Action, nil, opts...))
+	}
+	return p, nil
+}
+
+func checkAction(filter, action string, allowed ...string) error {
+	if action == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if a == action {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: action %q is not one of %v", filter, action, allowed)
+}
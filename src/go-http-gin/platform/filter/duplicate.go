@@ -0,0 +1,158 @@
+package filter
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+type feedKey struct{}
+
+// WithFeed returns a copy of ctx naming the feed the item being filtered
+// is posted to.
+func WithFeed(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, feedKey{}, name)
+}
+
+// Feed returns the feed named by WithFeed, or newsfeed.DefaultFeed.
+func Feed(ctx context.Context) string {
+	if name, ok := ctx.Value(feedKey{}).(string); ok {
+		return name
+	}
+	return newsfeed.DefaultFeed
+}
+
+// DefaultMaxDuplicates is how many posts a Duplicate filter remembers unless
+// WithMaxEntries says otherwise.
+const DefaultMaxDuplicates = 100000
+
+type duplicateKey struct {
+	feed string
+	sum  [sha256.Size]byte
+}
+
+type seenPost struct {
+	key  duplicateKey
+	at   time.Time
+	elem *list.Element
+}
+
+// Duplicate acts on items whose title and post, ignoring case and spacing,
+// were already seen in the same feed within the window. It rejects them
+// unless another action is given. Once it remembers its maximum number of
+// posts, a new post makes it forget the oldest one.
+type Duplicate struct {
+	action     string
+	window     time.Duration
+	now        func() time.Time
+	maxEntries int
+
+	mu   sync.Mutex
+	seen map[duplicateKey]*seenPost
+	// order lists the posts oldest first, which is also the order in which
+	// they leave the window.
+	order *list.List
+}
+
+// DuplicateOption configures a Duplicate created with NewDuplicate.
+type DuplicateOption func(*Duplicate)
+
+// WithMaxEntries caps the number of posts remembered at once.
+func WithMaxEntries(n int) DuplicateOption {
+	return func(d *Duplicate) {
+		d.maxEntries = n
+	}
+}
+
+func NewDuplicate(window time.Duration, action string, now func() time.Time, opts ...DuplicateOption) *Duplicate {
+	if action == "" {
+		action = Reject
+	}
+	if now == nil {
+		now = time.Now
+	}
+	d := &Duplicate{
+		action:     action,
+		window:     window,
+		now:        now,
+		maxEntries: DefaultMaxDuplicates,
+		seen:       map[duplicateKey]*seenPost{},
+		order:      list.New(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *Duplicate) Name() string {
+	return "duplicate"
+}
+
+func (d *Duplicate) Check(ctx context.Context, item *newsfeed.Item) Decision {
+	key := duplicateKey{
+		feed: Feed(ctx),
+		sum:  sha256.Sum256([]byte(normalize(item.Title) + "\x00" + normalize(item.Post))),
+	}
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+	if _, ok := d.seen[key]; ok {
+		return Decision{Action: d.action, Reason: "same content was posted within " + d.window.String()}
+	}
+	for d.maxEntries > 0 && len(d.seen) >= d.maxEntries {
+		d.remove(d.order.Front().Value.(*seenPost))
+	}
+	p := &seenPost{key: key, at: now}
+	p.elem = d.order.PushBack(p)
+	d.seen[key] = p
+	return Decision{Undo: func() { d.forget(p) }}
+}
+
+// Len returns the number of posts remembered.
+func (d *Duplicate) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.seen)
+}
+
+// forget drops the record of p, so that content rejected by a later filter
+// can be posted again once corrected.
+func (d *Duplicate) forget(p *seenPost) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.seen[p.key] == p {
+		d.remove(p)
+	}
+}
+
+// expire drops the posts older than the window, stopping at the first one
+// that is not. It must be called with d.mu held.
+func (d *Duplicate) expire(now time.Time) {
+	for front := d.order.Front(); front != nil; front = d.order.Front() {
+		p := front.Value.(*seenPost)
+		if now.Sub(p.at) < d.window {
+			return
+		}
+		d.remove(p)
+	}
+}
+
+// remove must be called with d.mu held.
+func (d *Duplicate) remove(p *seenPost) {
+	d.order.Remove(p.elem)
+	delete(d.seen, p.key)
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,127 @@
+// Package filter runs incoming newsfeed items through a chain of content
+// filters before they are stored.
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"newsfeeder/platform/newsfeed"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func tracer() trace.Tracer {
+	return otel.Tracer("newsfeeder/platform/filter")
+}
+
+// Actions a filter can take on an item.
+const (
+	// Allow leaves the item alone and is not recorded.
+	Allow = ""
+	// Modify means the filter rewrote the item.
+	Modify = "modify"
+	// Flag holds the item for moderation.
+	Flag = "flag"
+	// Reject stops the item from being stored.
+	Reject = "reject"
+)
+
+// Filter inspects an item and may rewrite it. Filters must be safe for
+// concurrent use.
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, item *newsfeed.Item) Decision
+}
+
+// Decision is a filter's verdict; Action is one of the constants above.
+type Decision struct {
+	Action string
+	Reason string
+	// Undo, if set, reverts what the filter recorded about the item. The
+	// pipeline calls it when a later filter rejects the item.
+	Undo func()
+}
+
+// RejectedError is returned by Pipeline.Run when a filter rejects an item.
+type RejectedError struct {
+	Decision newsfeed.FilterDecision
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("rejected by %s filter: %s", e.Decision.Filter, e.Decision.Reason)
+}
+
+// Pipeline runs filters in the order they were registered.
+type Pipeline struct {
+	mu      sync.RWMutex
+	filters []Filter
+}
+
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{
+		filters: filters,
+	}
+}
+
+// Register appends f to the pipeline.
+func (p *Pipeline) Register(f Filter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filters = append(p.filters, f)
+}
+
+// Run passes item through every filter, recording their decisions on it. It
+// stops at the first rejection, undoing what earlier filters recorded, and
+// returns a *RejectedError. A flagged item is marked pending so that it goes
+// to the moderation queue. A nil Pipeline accepts everything.
+func (p *Pipeline) Run(ctx context.Context, item *newsfeed.Item) error {
+	if p == nil {
+		return nil
+	}
+	ctx, span := tracer().Start(ctx, "Pipeline.Run")
+	defer span.End()
+
+	p.mu.RLock()
+	filters := p.filters
+	p.mu.RUnlock()
+
+	flagged := false
+	var undo []func()
+	for _, f := range filters {
+		d := f.Check(ctx, item)
+		if d.Undo != nil {
+			undo = append(undo, d.Undo)
+		}
+		if d.Action == Allow {
+			continue
+		}
+		decision := newsfeed.FilterDecision{
+			Filter: f.Name(),
+			Action: d.Action,
+			Reason: d.Reason,
+		}
+		item.FilterDecisions = append(item.FilterDecisions, decision)
+		span.AddEvent("filter decision", trace.WithAttributes(
+			attribute.String("filter.name", decision.Filter),
+			attribute.String("filter.action", decision.Action),
+		))
+
+		switch d.Action {
+		case Reject:
+			for _, u := range undo {
+				u()
+			}
+			return &RejectedError{Decision: decision}
+		case Flag:
+			flagged = true
+		}
+	}
+	if flagged {
+		item.Status = newsfeed.StatusPending
+	}
+	return nil
+}
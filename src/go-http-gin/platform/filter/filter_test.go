@@ -0,0 +1,231 @@
+package filter
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+func TestProfanityMasks(t *testing.T) {
+	p := NewPipeline(NewProfanity([]string{"darn"}, ""))
+	item := &newsfeed.Item{Title: "Darn it", Post: "the darned darn build"}
+
+	if err := p.Run(context.Background(), item); err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Title != "**** it" || item.Post != "the darned **** build" {
+		t.Errorf("Masked to %q / %q", item.Title, item.Post)
+	}
+	if len(item.FilterDecisions) != 1 || item.FilterDecisions[0].Action != Modify {
+		t.Errorf("Decisions = %+v", item.FilterDecisions)
+	}
+}
+
+func TestProfanityMatchesNonASCIIWords(t *testing.T) {
+	p := NewPipeline(NewProfanity([]string{"scheiße", "merde"}, ""))
+	item := &newsfeed.Item{Title: "Scheiße!", Post: "scheißegal, démerder, MERDE"}
+
+	if err := p.Run(context.Background(), item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "*******!" || item.Post != "scheißegal, démerder, *****" {
+		t.Errorf("Masked to %q / %q", item.Title, item.Post)
+	}
+}
+
+func TestProfanityWithoutWordsAllowsEverything(t *testing.T) {
+	for _, words := range [][]string{nil, {""}} {
+		item := &newsfeed.Item{Title: "Hello", Post: "World"}
+		if d := NewProfanity(words, Reject).Check(context.Background(), item); d.Action != Allow {
+			t.Errorf("NewProfanity(%q) decided %+v", words, d)
+		}
+	}
+}
+
+func TestLinkBlocklistRejects(t *testing.T) {
+	p := NewPipeline(NewLinkBlocklist([]string{"spam.example"}, ""))
+
+	err := p.Run(context.Background(), &newsfeed.Item{Post: "see https://www.SPAM.example/deal"})
+	rejected, ok := err.(*RejectedError)
+	if !ok || rejected.Decision.Filter != "link_blocklist" {
+		t.Fatalf("Run() = %v, want a link_blocklist rejection", err)
+	}
+
+	if err := p.Run(context.Background(), &newsfeed.Item{Post: "see https://notspam.example.org"}); err != nil {
+		t.Errorf("Run() rejected an allowed link: %v", err)
+	}
+}
+
+func TestMaxLinksFlagsForModeration(t *testing.T) {
+	p := NewPipeline(NewMaxLinks(1, ""))
+	item := &newsfeed.Item{Post: "http://a.example http://b.example"}
+
+	if err := p.Run(context.Background(), item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Status != newsfeed.StatusPending {
+		t.Errorf("Status = %q, want pending", item.Status)
+	}
+}
+
+func TestDuplicateWithinWindow(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	p := NewPipeline(NewDuplicate(time.Hour, "", func() time.Time { return now }))
+	ctx := context.Background()
+
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Hello", Post: "World"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Run(ctx, &newsfeed.Item{Title: "hello", Post: "  world "}); err == nil {
+		t.Errorf("Duplicate was accepted")
+	}
+
+	now = now.Add(time.Hour)
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Hello", Post: "World"}); err != nil {
+		t.Errorf("Repost after the window was rejected: %v", err)
+	}
+}
+
+func TestDuplicateForgetsRejectedItems(t *testing.T) {
+	p := NewPipeline(
+		NewDuplicate(time.Hour, "", nil),
+		NewLinkBlocklist([]string{"spam.example"}, ""),
+	)
+	ctx := context.Background()
+
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Deal", Post: "http://spam.example"}); err == nil {
+		t.Fatal("Blocked link was accepted")
+	}
+	// A retry is rejected for its link again, not as a duplicate.
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Deal", Post: "http://spam.example"}); err == nil ||
+		err.(*RejectedError).Decision.Filter != "link_blocklist" {
+		t.Errorf("Resubmission got %v, want the link_blocklist rejection again", err)
+	}
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Deal", Post: "http://shop.example"}); err != nil {
+		t.Errorf("Corrected post was rejected: %v", err)
+	}
+}
+
+func TestDuplicateIsPerFeed(t *testing.T) {
+	p := NewPipeline(NewDuplicate(time.Hour, "", nil))
+	ctx := context.Background()
+
+	if err := p.Run(ctx, &newsfeed.Item{Title: "Hello", Post: "World"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Run(WithFeed(ctx, "sports"), &newsfeed.Item{Title: "Hello", Post: "World"}); err != nil {
+		t.Errorf("Same post in another feed was rejected: %v", err)
+	}
+	if err := p.Run(WithFeed(ctx, newsfeed.DefaultFeed), &newsfeed.Item{Title: "Hello", Post: "World"}); err == nil {
+		t.Errorf("Duplicate in the default feed was accepted")
+	}
+}
+
+func TestDuplicateDropsOldPosts(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDuplicate(time.Hour, "", func() time.Time { return now }, WithMaxEntries(2))
+	p := NewPipeline(d)
+	ctx := context.Background()
+
+	for _, post := range []string{"one", "two", "three"} {
+		if err := p.Run(ctx, &newsfeed.Item{Post: post}); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Got %d posts remembered, want 2", d.Len())
+	}
+	if err := p.Run(ctx, &newsfeed.Item{Post: "one"}); err != nil {
+		t.Errorf("Evicted post was rejected: %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if err := p.Run(ctx, &newsfeed.Item{Post: "four"}); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 1 {
+		t.Errorf("Got %d posts remembered after the window, want 1", d.Len())
+	}
+}
+
+func TestRunStopsAtFirstRejection(t *testing.T) {
+	p := NewPipeline(
+		NewLinkBlocklist([]string{"spam.example"}, ""),
+		NewProfanity([]string{"darn"}, ""),
+	)
+	item := &newsfeed.Item{Post: "darn http://spam.example"}
+
+	p.Run(context.Background(), item)
+
+	if len(item.FilterDecisions) != 1 || strings.Contains(item.Post, "*") {
+		t.Errorf("Filters ran after the rejection: %+v", item)
+	}
+}
+
+type shouting struct{}
+
+func (shouting) Name() string {
+	return "shouting"
+}
+
+func (shouting) Check(ctx context.Context, item *newsfeed.Item) Decision {
+	if item.Title != "" && item.Title == strings.ToUpper(item.Title) {
+		item.Title = strings.ToLower(item.Title)
+		return Decision{Action: Modify, Reason: "title was all caps"}
+	}
+	return Decision{}
+}
+
+func TestRegisterCustomFilter(t *testing.T) {
+	p := NewPipeline()
+	p.Register(shouting{})
+	item := &newsfeed.Item{Title: "HELLO"}
+
+	p.Run(context.Background(), item)
+
+	if item.Title != "hello" || item.FilterDecisions[0].Filter != "shouting" {
+		t.Errorf("Custom filter did not run: %+v", item)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filters.yaml")
+	ioutil.WriteFile(path, []byte(`
+profanity:
+  words: [darn]
+max_links:
+  max: 0
+  action: reject
+`), 0644)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cfg.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := &newsfeed.Item{Post: "darn"}
+	if err := p.Run(context.Background(), item); err != nil || item.Post != "****" {
+		t.Errorf("Configured profanity filter did not mask: %v, %q", err, item.Post)
+	}
+	if err := p.Run(context.Background(), &newsfeed.Item{Post: "http://a.example"}); err == nil {
+		t.Errorf("Configured max_links filter did not reject")
+	}
+}
+
+func TestConfigBuildRejectsBadAction(t *testing.T) {
+	cfg := Config{MaxLinks: &MaxLinksConfig{Max: 1, Action: Modify}}
+	if _, err := cfg.Build(); err == nil {
+		t.Errorf("Build accepted modify for max_links")
+	}
+}
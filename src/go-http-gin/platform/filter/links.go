@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+
+func links(item *newsfeed.Item) []string {
+	return append(linkPattern.FindAllString(item.Title, -1), linkPattern.FindAllString(item.Post, -1)...)
+}
+
+// LinkBlocklist acts on items linking to a listed domain or any of its
+// subdomains. It rejects them unless another action is given.
+type LinkBlocklist struct {
+	action  string
+	domains []string
+}
+
+func NewLinkBlocklist(domains []string, action string) *LinkBlocklist {
+	lower := make([]string, len(domains))
+	for i, d := range domains {
+		lower[i] = strings.ToLower(strings.TrimPrefix(d, "."))
+	}
+	if action == "" {
+		action = Reject
+	}
+	return &LinkBlocklist{
+		action:  action,
+		domains: lower,
+	}
+}
+
+func (b *LinkBlocklist) Name() string {
+	return "link_blocklist"
+}
+
+func (b *LinkBlocklist) Check(ctx context.Context, item *newsfeed.Item) Decision {
+	for _, link := range links(item) {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := strings.ToLower(u.Hostname())
+		for _, d := range b.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return Decision{Action: b.action, Reason: "links to blocked domain " + d}
+			}
+		}
+	}
+	return Decision{}
+}
+
+// MaxLinks acts on items with more than max links. It flags them unless
+// another action is given.
+type MaxLinks struct {
+	action string
+	max    int
+}
+
+func NewMaxLinks(max int, action string) *MaxLinks {
+	if action == "" {
+		action = Flag
+	}
+	return &MaxLinks{
+		action: action,
+		max:    max,
+	}
+}
+
+func (m *MaxLinks) Name() string {
+	return "max_links"
+}
+
+func (m *MaxLinks) Check(ctx context.Context, item *newsfeed.Item) Decision {
+	if n := len(links(item)); n > m.max {
+		return Decision{Action: m.action, Reason: fmt.Sprintf("%d links, at most %d allowed", n, m.max)}
+	}
+	return Decision{}
+}
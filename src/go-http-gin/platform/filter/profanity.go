@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+// Profanity masks listed words in the title and post. With an Action other
+// than Modify it flags or rejects the item instead of masking.
+type Profanity struct {
+	action string
+	// re matches any listed word, longest first; nil if there are none.
+	re *regexp.Regexp
+}
+
+// NewProfanity returns a filter for words, matched ignoring case and only
+// as whole words. Empty words are ignored; with none left the filter
+// allows everything.
+func NewProfanity(words []string, action string) *Profanity {
+	if action == "" {
+		action = Modify
+	}
+	p := &Profanity{action: action}
+
+	var quoted []string
+	for _, w := range words {
+		if w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return p
+	}
+	// Go's \b only knows ASCII letters, so word boundaries are checked by
+	// matches instead. Longer words go first so that a word is not missed
+	// because a shorter one matched its start.
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	p.re = regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+	return p
+}
+
+func (p *Profanity) Name() string {
+	return "profanity"
+}
+
+func (p *Profanity) Check(ctx context.Context, item *newsfeed.Item) Decision {
+	found := len(p.matches(item.Title)) + len(p.matches(item.Post))
+	if found == 0 {
+		return Decision{}
+	}
+	reason := fmt.Sprintf("%d profane word(s)", found)
+	if p.action != Modify {
+		return Decision{Action: p.action, Reason: reason}
+	}
+	item.Title = p.mask(item.Title)
+	item.Post = p.mask(item.Post)
+	return Decision{Action: Modify, Reason: reason + " masked"}
+}
+
+// matches returns the positions of the listed words in s that stand alone,
+// not as part of a longer word.
+func (p *Profanity) matches(s string) [][]int {
+	if p.re == nil {
+		return nil
+	}
+	var found [][]int
+	for _, m := range p.re.FindAllStringIndex(s, -1) {
+		before, _ := utf8.DecodeLastRuneInString(s[:m[0]])
+		after, _ := utf8.DecodeRuneInString(s[m[1]:])
+		if !isWordRune(before) && !isWordRune(after) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+func (p *Profanity) mask(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range p.matches(s) {
+		b.WriteString(s[last:m[0]])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(s[m[0]:m[1]])))
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
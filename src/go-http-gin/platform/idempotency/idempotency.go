@@ -0,0 +1,154 @@
+// Package idempotency remembers responses by client-supplied key so retried
+// requests can be answered without being executed twice.
+package idempotency
+
+import (
+	"container/list"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMismatch means the key was used before with a different request.
+	ErrMismatch = errors.New("idempotency key was used with a different request")
+	// ErrInProgress means the first request with the key has not finished.
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// Response is a stored reply.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type entry struct {
+	key         string
+	fingerprint string
+	createdAt   time.Time
+	response    *Response
+	elem        *list.Element
+}
+
+// DefaultMaxKeys is how many keys a Store holds unless WithMaxKeys says
+// otherwise.
+const DefaultMaxKeys = 100000
+
+// Store holds responses in memory for a fixed window after the first
+// request with each key. Once it holds its maximum number of keys, a new
+// key evicts the oldest one, whose requests are then handled afresh.
+type Store struct {
+	window  time.Duration
+	now     func() time.Time
+	maxKeys int
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// order lists the entries oldest first, which is also the order in
+	// which they expire.
+	order *list.List
+}
+
+// Option configures a Store created with NewStore.
+type Option func(*Store)
+
+// WithMaxKeys caps the number of keys held at once.
+func WithMaxKeys(n int) Option {
+	return func(s *Store) {
+		s.maxKeys = n
+	}
+}
+
+func NewStore(window time.Duration, now func() time.Time, opts ...Option) *Store {
+	if now == nil {
+		now = time.Now
+	}
+	s := &Store{
+		window:  window,
+		now:     now,
+		maxKeys: DefaultMaxKeys,
+		entries: map[string]*entry{},
+		order:   list.New(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Begin claims key for a request identified by fingerprint. It returns the
+// stored response if the request was already answered, or nil if the caller
+// should handle the request and then call Complete or Abandon.
+func (s *Store) Begin(key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+
+	e, ok := s.entries[key]
+	if !ok {
+		for s.maxKeys > 0 && len(s.entries) >= s.maxKeys {
+			s.remove(s.order.Front().Value.(*entry))
+		}
+		e = &entry{key: key, fingerprint: fingerprint, createdAt: now}
+		e.elem = s.order.PushBack(e)
+		s.entries[key] = e
+		return nil, nil
+	}
+	if e.fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if e.response == nil {
+		return nil, ErrInProgress
+	}
+	return e.response, nil
+}
+
+// Complete stores the response for a key claimed with Begin.
+func (s *Store) Complete(key string, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = &resp
+	}
+}
+
+// Abandon releases a key claimed with Begin without storing a response, so
+// the request can be retried.
+func (s *Store) Abandon(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		s.remove(e)
+	}
+}
+
+// Len returns the number of keys held.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// expire drops the entries older than the window, stopping at the first
+// one that is not. It must be called with s.mu held.
+func (s *Store) expire(now time.Time) {
+	for front := s.order.Front(); front != nil; front = s.order.Front() {
+		e := front.Value.(*entry)
+		if now.Sub(e.createdAt) < s.window {
+			return
+		}
+		s.remove(e)
+	}
+}
+
+// remove must be called with s.mu held.
+func (s *Store) remove(e *entry) {
+	s.order.Remove(e.elem)
+	delete(s.entries, e.key)
+}
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBeginReplaysCompletedResponse(t *testing.T) {
+	s := NewStore(time.Hour, nil)
+
+	if resp, err := s.Begin("k", "a"); resp != nil || err != nil {
+		t.Fatalf("First Begin() = %v, %v, want nil, nil", resp, err)
+	}
+	s.Complete("k", Response{Status: http.StatusCreated, Body: []byte("done")})
+
+	resp, err := s.Begin("k", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || resp.Status != http.StatusCreated || string(resp.Body) != "done" {
+		t.Errorf("Begin() = %+v, want the stored response", resp)
+	}
+}
+
+func TestBeginRejectsDifferentRequest(t *testing.T) {
+	s := NewStore(time.Hour, nil)
+	s.Begin("k", "a")
+	s.Complete("k", Response{Status: http.StatusNoContent})
+
+	if _, err := s.Begin("k", "b"); err != ErrMismatch {
+		t.Errorf("Begin() = %v, want ErrMismatch", err)
+	}
+}
+
+func TestBeginWhileInProgress(t *testing.T) {
+	s := NewStore(time.Hour, nil)
+	s.Begin("k", "a")
+
+	if _, err := s.Begin("k", "a"); err != ErrInProgress {
+		t.Errorf("Begin() = %v, want ErrInProgress", err)
+	}
+
+	s.Abandon("k")
+	if resp, err := s.Begin("k", "a"); resp != nil || err != nil {
+		t.Errorf("Begin() after Abandon = %v, %v, want a fresh claim", resp, err)
+	}
+}
+
+func TestKeysExpireAfterWindow(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(time.Hour, func() time.Time { return now })
+	s.Begin("k", "a")
+	s.Complete("k", Response{Status: http.StatusNoContent})
+
+	now = now.Add(time.Hour)
+
+	if resp, err := s.Begin("k", "b"); resp != nil || err != nil {
+		t.Errorf("Begin() after the window = %v, %v, want a fresh claim", resp, err)
+	}
+}
+
+func TestExpiredKeysAreDropped(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore(time.Hour, func() time.Time { return now })
+	s.Begin("old", "a")
+	now = now.Add(30 * time.Minute)
+	s.Begin("new", "a")
+
+	now = now.Add(30 * time.Minute)
+	s.Begin("newest", "a")
+
+	if n := s.Len(); n != 2 {
+		t.Errorf("Store holds %d keys, want 2", n)
+	}
+}
+
+func TestStoreEvictsOldestKeyWhenFull(t *testing.T) {
+	s := NewStore(time.Hour, nil, WithMaxKeys(2))
+	for _, key := range []string{"a", "b", "c"} {
+		s.Begin(key, "x")
+		s.Complete(key, Response{Status: http.StatusNoContent})
+	}
+
+	if n := s.Len(); n != 2 {
+		t.Errorf("Store holds %d keys, want 2", n)
+	}
+	if resp, _ := s.Begin("a", "x"); resp != nil {
+		t.Error("Oldest key was not evicted")
+	}
+	if resp, _ := s.Begin("c", "x"); resp == nil {
+		t.Error("Newest key was evicted")
+	}
+}
@@ -0,0 +1,79 @@
+// Package logging builds the service's structured logger and carries it,
+// along with the request ID, through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config selects the minimum level and how much low-level output is kept.
+type Config struct {
+	Level slog.Level
+	// SampleEvery keeps one in every SampleEvery records below warning
+	// level. Zero or one keeps them all.
+	SampleEvery int
+}
+
+// ConfigFromEnv reads LOG_LEVEL (debug, info, warn or error) and
+// LOG_SAMPLE_EVERY.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Level: slog.LevelInfo}
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		if err := cfg.Level.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+			return cfg, fmt.Errorf("LOG_LEVEL: %v", err)
+		}
+	}
+	if every := os.Getenv("LOG_SAMPLE_EVERY"); every != "" {
+		n, err := strconv.Atoi(every)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("LOG_SAMPLE_EVERY must be a non-negative integer")
+		}
+		cfg.SampleEvery = n
+	}
+	return cfg, nil
+}
+
+// New returns a logger writing JSON lines to w.
+func New(cfg Config, w io.Writer) *slog.Logger {
+	var h slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: cfg.Level})
+	if cfg.SampleEvery > 1 {
+		h = newSampler(h, cfg.SampleEvery)
+	}
+	return slog.New(h)
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx, or slog.Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, with the ID
+// added to the context's logger.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return NewContext(ctx, FromContext(ctx).With("request_id", id))
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
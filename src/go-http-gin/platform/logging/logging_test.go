@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func lines(buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		json.Unmarshal([]byte(line), &record)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNewFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Config{Level: slog.LevelWarn}, &buf)
+
+	logger.Info("quiet")
+	logger.Warn("loud")
+
+	records := lines(&buf)
+	if len(records) != 1 || records[0]["msg"] != "loud" {
+		t.Errorf("Logged %v, want only the warning", records)
+	}
+}
+
+func TestSamplingKeepsWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(Config{Level: slog.LevelDebug, SampleEvery: 3}, &buf)
+
+	for i := 0; i < 6; i++ {
+		logger.Info("info")
+	}
+	logger.With("k", "v").Info("info")
+	logger.Error("error")
+
+	infos, errors := 0, 0
+	for _, record := range lines(&buf) {
+		switch record["msg"] {
+		case "info":
+			infos++
+		case "error":
+			errors++
+		}
+	}
+	if infos != 3 || errors != 1 {
+		t.Errorf("Kept %d infos and %d errors, want 3 and 1", infos, errors)
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(Config{}, &buf))
+
+	ctx = WithRequestID(ctx, "abc123")
+	FromContext(ctx).Info("handled")
+
+	if RequestID(ctx) != "abc123" {
+		t.Errorf("RequestID() = %q", RequestID(ctx))
+	}
+	if records := lines(&buf); len(records) != 1 || records[0]["request_id"] != "abc123" {
+		t.Errorf("Logged %v, want the request ID attached", records)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	os.Setenv("LOG_LEVEL", "debug")
+	os.Setenv("LOG_SAMPLE_EVERY", "10")
+	defer os.Unsetenv("LOG_LEVEL")
+	defer os.Unsetenv("LOG_SAMPLE_EVERY")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != slog.LevelDebug || cfg.SampleEvery != 10 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	os.Setenv("LOG_LEVEL", "chatty")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Errorf("ConfigFromEnv accepted an unknown level")
+	}
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// sampler passes warnings and errors through and keeps one in every n of
+// the remaining records.
+type sampler struct {
+	next  slog.Handler
+	n     uint64
+	count *uint64
+}
+
+func newSampler(next slog.Handler, n int) *sampler {
+	return &sampler{
+		next:  next,
+		n:     uint64(n),
+		count: new(uint64),
+	}
+}
+
+func (s *sampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.next.Enabled(ctx, level)
+}
+
+func (s *sampler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && (atomic.AddUint64(s.count, 1)-1)%s.n != 0 {
+		return nil
+	}
+	return s.next.Handle(ctx, r)
+}
+
+// WithAttrs and WithGroup share the counter so that derived loggers are
+// sampled together.
+func (s *sampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampler{next: s.next.WithAttrs(attrs), n: s.n, count: s.count}
+}
+
+func (s *sampler) WithGroup(name string) slog.Handler {
+	return &sampler{next: s.next.WithGroup(name), n: s.n, count: s.count}
+}
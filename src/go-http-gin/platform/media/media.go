@@ -0,0 +1,245 @@
+// Package media stores files attached to newsfeed items and generates
+// thumbnails for images.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for DecodeConfig and Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("file type is not allowed")
+)
+
+// DefaultTypes are the content types accepted when Limits.Types is empty.
+var DefaultTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"application/pdf",
+	"text/plain",
+}
+
+// Limits restricts what Library.Save accepts.
+type Limits struct {
+	// MaxBytes is the largest file accepted.
+	MaxBytes int64
+	// MaxPixels guards against images that decompress to huge bitmaps.
+	MaxPixels int
+	// Types lists the accepted sniffed content types.
+	Types []string
+	// ThumbnailSize is the longest side of generated thumbnails.
+	ThumbnailSize int
+}
+
+// DefaultLimits accept 10 MB files and 40 megapixel images.
+var DefaultLimits = Limits{
+	MaxBytes:      10 << 20,
+	MaxPixels:     40 << 20,
+	Types:         DefaultTypes,
+	ThumbnailSize: 256,
+}
+
+// Library saves uploads to a Storage along with their metadata.
+type Library struct {
+	storage   Storage
+	limits    Limits
+	urlPrefix string
+}
+
+// NewLibrary returns a library whose attachment URLs start with urlPrefix,
+// e.g. "/media/".
+func NewLibrary(storage Storage, limits Limits, urlPrefix string) *Library {
+	if len(limits.Types) == 0 {
+		limits.Types = DefaultTypes
+	}
+	if limits.ThumbnailSize <= 0 {
+		limits.ThumbnailSize = DefaultLimits.ThumbnailSize
+	}
+	return &Library{
+		storage:   storage,
+		limits:    limits,
+		urlPrefix: urlPrefix,
+	}
+}
+
+// Save checks the size and sniffed content type of r, stores it and, for
+// images, a thumbnail.
+func (l *Library) Save(ctx context.Context, filename string, r io.Reader) (newsfeed.Attachment, error) {
+	content, err := ioutil.ReadAll(io.LimitReader(r, l.limits.MaxBytes+1))
+	if err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	if int64(len(content)) > l.limits.MaxBytes {
+		return newsfeed.Attachment{}, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(content)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if !l.allowed(contentType) {
+		return newsfeed.Attachment{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	id := newID()
+	a := newsfeed.Attachment{
+		ID:          id,
+		Filename:    filepath.Base(filename),
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		URL:         l.urlPrefix + id,
+	}
+
+	if strings.HasPrefix(contentType, "image/") {
+		thumb, err := l.thumbnail(content, &a)
+		if err != nil {
+			return newsfeed.Attachment{}, err
+		}
+		if err := l.storage.Put(ctx, id+".thumb", bytes.NewReader(thumb)); err != nil {
+			return newsfeed.Attachment{}, err
+		}
+		a.ThumbnailURL = l.urlPrefix + id + "/thumbnail"
+	}
+
+	if err := l.storage.Put(ctx, id, bytes.NewReader(content)); err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	meta, err := json.Marshal(metadata{Attachment: a, UploadedAt: time.Now().UTC()})
+	if err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	if err := l.storage.Put(ctx, id+".json", bytes.NewReader(meta)); err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	return a, nil
+}
+
+// metadata is what Save stores next to a file. UploadedAt lets Prune tell
+// uploads still waiting to be attached from abandoned ones.
+type metadata struct {
+	newsfeed.Attachment
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// Get returns the metadata of a saved file.
+func (l *Library) Get(ctx context.Context, id string) (newsfeed.Attachment, error) {
+	var a newsfeed.Attachment
+	rc, err := l.storage.Get(ctx, id+".json")
+	if err != nil {
+		return a, err
+	}
+	defer rc.Close()
+	err = json.NewDecoder(rc).Decode(&a)
+	return a, err
+}
+
+// Open returns a saved file's content.
+func (l *Library) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	return l.storage.Get(ctx, id)
+}
+
+// OpenThumbnail returns an image's thumbnail, which is a PNG unless the
+// original was a JPEG.
+func (l *Library) OpenThumbnail(ctx context.Context, id string) (io.ReadCloser, error) {
+	return l.storage.Get(ctx, id+".thumb")
+}
+
+// Prune deletes the files saved before cutoff for which inUse returns
+// false, along with their thumbnails, and returns how many it deleted. The
+// metadata goes last, so a file whose deletion fails is retried next time.
+func (l *Library) Prune(ctx context.Context, cutoff time.Time, inUse func(id string) bool) (int, error) {
+	keys, err := l.storage.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, key := range keys {
+		id := strings.TrimSuffix(key, ".json")
+		if id == key || inUse(id) {
+			continue
+		}
+		var m metadata
+		rc, err := l.storage.Get(ctx, key)
+		if err != nil {
+			return pruned, err
+		}
+		err = json.NewDecoder(rc).Decode(&m)
+		rc.Close()
+		if err != nil {
+			return pruned, fmt.Errorf("%s: %v", key, err)
+		}
+		if !m.UploadedAt.Before(cutoff) {
+			continue
+		}
+		for _, k := range []string{id, id + ".thumb", key} {
+			if err := l.storage.Delete(ctx, k); err != nil && !errors.Is(err, ErrNotFound) {
+				return pruned, err
+			}
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+func (l *Library) allowed(contentType string) bool {
+	for _, t := range l.limits.Types {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// thumbnail decodes the image, records its size on a and returns the
+// encoded thumbnail.
+func (l *Library) thumbnail(content []byte, a *newsfeed.Attachment) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decode image: %v", ErrUnsupportedType, err)
+	}
+	if l.limits.MaxPixels > 0 && cfg.Width*cfg.Height > l.limits.MaxPixels {
+		return nil, ErrTooLarge
+	}
+	a.Width, a.Height = cfg.Width, cfg.Height
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decode image: %v", ErrUnsupportedType, err)
+	}
+
+	var buf bytes.Buffer
+	thumb := thumbnail(img, l.limits.ThumbnailSize)
+	if a.ContentType == "image/jpeg" {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	return buf.Bytes(), err
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,190 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLibrary(t *testing.T, limits Limits) *Library {
+	t.Helper()
+	storage, err := NewLocalDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewLibrary(storage, limits, "/media/")
+}
+
+func TestSaveImage(t *testing.T) {
+	lib := newLibrary(t, DefaultLimits)
+	ctx := context.Background()
+
+	src := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+	for x := 0; x < 500; x++ {
+		for y := 0; y < 500; y++ {
+			src.Set(x, y, color.White)
+		}
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, src, nil)
+
+	a, err := lib.Save(ctx, "../photo.jpg", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Filename != "photo.jpg" || a.ContentType != "image/jpeg" || a.Width != 1000 || a.Height != 500 {
+		t.Errorf("attachment = %+v", a)
+	}
+	if a.URL != "/media/"+a.ID || a.ThumbnailURL != "/media/"+a.ID+"/thumbnail" {
+		t.Errorf("urls = %q, %q", a.URL, a.ThumbnailURL)
+	}
+
+	got, err := lib.Get(ctx, a.ID)
+	if err != nil || got != a {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+
+	rc, err := lib.OpenThumbnail(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	thumb, err := jpeg.Decode(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 256 || b.Dy() != 128 {
+		t.Fatalf("thumbnail is %v, want 256x128", b)
+	}
+	// The left half of the source was white, the right half black.
+	if r, _, _, _ := thumb.At(10, 64).RGBA(); r < 0xf000 {
+		t.Errorf("left of thumbnail is not white: %x", r)
+	}
+	if r, _, _, _ := thumb.At(245, 64).RGBA(); r > 0x1000 {
+		t.Errorf("right of thumbnail is not black: %x", r)
+	}
+}
+
+func TestSaveSmallImageKeepsSize(t *testing.T) {
+	lib := newLibrary(t, DefaultLimits)
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 16, 8)))
+
+	a, err := lib.Save(context.Background(), "icon.png", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, _ := lib.OpenThumbnail(context.Background(), a.ID)
+	defer rc.Close()
+	cfg, err := png.DecodeConfig(rc)
+	if err != nil || cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("thumbnail = %+v, %v", cfg, err)
+	}
+}
+
+func TestSaveText(t *testing.T) {
+	lib := newLibrary(t, DefaultLimits)
+	a, err := lib.Save(context.Background(), "notes.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ContentType != "text/plain" || a.ThumbnailURL != "" || a.Size != 5 {
+		t.Errorf("attachment = %+v", a)
+	}
+	if _, err := lib.OpenThumbnail(context.Background(), a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OpenThumbnail err = %v, want ErrNotFound", err)
+	}
+
+	rc, err := lib.Open(context.Background(), a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if content, _ := ioutil.ReadAll(rc); string(content) != "hello" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestSaveLimits(t *testing.T) {
+	limits := DefaultLimits
+	limits.MaxBytes = 10
+	limits.MaxPixels = 100
+	lib := newLibrary(t, limits)
+	ctx := context.Background()
+
+	if _, err := lib.Save(ctx, "big.txt", strings.NewReader("eleven byte")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("big file: err = %v, want ErrTooLarge", err)
+	}
+
+	limits.MaxBytes = 1 << 20
+	lib = newLibrary(t, limits)
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 20, 20)))
+	if _, err := lib.Save(ctx, "wide.png", &buf); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("big image: err = %v, want ErrTooLarge", err)
+	}
+
+	// The sniffed type wins over the file name.
+	if _, err := lib.Save(ctx, "fake.png", strings.NewReader("<html><script>")); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("html: err = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestLocalDiskRejectsUnsafeKeys(t *testing.T) {
+	disk, err := NewLocalDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../etc/passwd", "a/b", ".hidden", ""} {
+		if _, err := disk.Get(context.Background(), key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) err = %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestPruneDeletesOldUnusedFiles(t *testing.T) {
+	storage, err := NewLocalDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib := NewLibrary(storage, DefaultLimits, "/media/")
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	orphan, err := lib.Save(ctx, "orphan.png", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, err := lib.Save(ctx, "used.txt", strings.NewReader("attached"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inUse := func(id string) bool { return id == used.ID }
+
+	if n, err := lib.Prune(ctx, time.Now().Add(-time.Hour), inUse); err != nil || n != 0 {
+		t.Errorf("Prune before the files are old = %d, %v; want 0", n, err)
+	}
+	if n, err := lib.Prune(ctx, time.Now().Add(time.Hour), inUse); err != nil || n != 1 {
+		t.Errorf("Prune = %d, %v; want 1", n, err)
+	}
+
+	if _, err := lib.Get(ctx, orphan.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(orphan) err = %v, want ErrNotFound", err)
+	}
+	if _, err := lib.Get(ctx, used.ID); err != nil {
+		t.Errorf("Get(used) err = %v", err)
+	}
+	keys, _ := storage.List(ctx)
+	if len(keys) != 2 {
+		t.Errorf("Stored keys %v, want the used file and its metadata", keys)
+	}
+}
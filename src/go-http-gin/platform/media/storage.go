@@ -0,0 +1,105 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var ErrNotFound = errors.New("media not found")
+
+// Storage keeps blobs by key.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List returns every key stored.
+	List(ctx context.Context) ([]string, error)
+}
+
+var validKey = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// LocalDisk stores each blob as a file in one directory.
+type LocalDisk struct {
+	dir string
+}
+
+// NewLocalDisk creates dir if needed.
+func NewLocalDisk(dir string) (*LocalDisk, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalDisk{dir: dir}, nil
+}
+
+// Put writes to a temporary file and renames it into place, so readers
+// never see a partial blob.
+func (d *LocalDisk) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(d.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (d *LocalDisk) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (d *LocalDisk) Delete(ctx context.Context, key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// List skips the temporary files of writes in progress.
+func (d *LocalDisk) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && validKey.MatchString(e.Name()) && e.Name()[0] != '.' {
+			keys = append(keys, e.Name())
+		}
+	}
+	return keys, nil
+}
+
+func (d *LocalDisk) path(key string) (string, error) {
+	if !validKey.MatchString(key) || key[0] == '.' {
+		return "", ErrNotFound
+	}
+	return filepath.Join(d.dir, key), nil
+}
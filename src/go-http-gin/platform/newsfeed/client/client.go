@@ -0,0 +1,147 @@
+// Package client is a Go client for the newsfeeder REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls a newsfeeder server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+	// feed is the named feed item calls go to; empty means /newsfeed.
+	feed string
+}
+
+// Option configures a Client created with New.
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sends token as a bearer token on every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries sets how many times idempotent calls are retried after a
+// network error, 429 or 5xx response, and the delay before the first retry;
+// each further retry doubles the delay.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		retries:    3,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do sends the request and decodes a JSON response into out, which may be
+// nil. GET and DELETE requests are retried; others are sent once.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) (http.Header, error) {
+	retry := method == http.MethodGet || method == http.MethodDelete
+	return c.doWithHeader(ctx, method, path, query, nil, retry, in, out)
+}
+
+// doWithHeader is do with extra request headers and explicit control over
+// retries.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, query url.Values, header http.Header, retry bool, in, out interface{}) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	attempts := 1
+	if retry {
+		attempts += c.retries
+	}
+	delay := c.backoff
+
+	for attempt := 1; ; attempt++ {
+		respHeader, err := c.send(ctx, method, u, header, body, out)
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return respHeader, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, u string, header http.Header, body []byte, out interface{}) (http.Header, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return resp.Header, decodeError(resp, content)
+	}
+	if out == nil || len(content) == 0 {
+		return resp.Header, nil
+	}
+	return resp.Header, json.Unmarshal(content, out)
+}
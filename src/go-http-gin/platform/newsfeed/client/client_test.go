@@ -0,0 +1,265 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"newsfeeder/httpd/handler"
+	"newsfeeder/platform/idempotency"
+	"newsfeeder/platform/media"
+	"newsfeeder/platform/newsfeed"
+	"newsfeeder/platform/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newServer runs the real handlers and returns a client for them.
+func newServer(t *testing.T, opts ...newsfeed.Option) (*Client, *newsfeed.Repo) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	feed := newsfeed.New(opts...)
+	hooks := webhook.NewRegistry()
+	dispatcher := webhook.NewDispatcher(hooks)
+	storage, err := media.NewLocalDisk(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	library := media.NewLibrary(storage, media.DefaultLimits, "/media/")
+
+	r := gin.New()
+	r.GET("/ping", handler.PingGet())
+	r.GET("/newsfeed", handler.NewsfeedGet(feed))
+	r.POST("/newsfeed", handler.Idempotency(idempotency.NewStore(time.Hour, nil), handler.DefaultIdempotentBodyBytes), handler.NewsfeedPost(feed, nil, library))
+	r.POST("/newsfeed/:id/engagement", handler.EngagementPost(feed))
+	r.POST("/media", handler.MediaPost(library))
+	r.GET("/media/:id", handler.MediaGet(library))
+	r.GET("/media/:id/thumbnail", handler.MediaThumbnailGet(library))
+	admin := r.Group("/admin", handler.RequireToken(adminToken))
+	admin.GET("/moderation", handler.ModerationGet(feed))
+	admin.POST("/moderation/:id/approve", handler.ModerationApprovePost(feed))
+	admin.POST("/moderation/:id/reject", handler.ModerationRejectPost(feed))
+	admin.GET("/webhooks", handler.WebhookGet(hooks))
+	admin.POST("/webhooks", handler.WebhookPost(hooks))
+	admin.DELETE("/webhooks/:id", handler.WebhookDelete(hooks))
+	admin.GET("/webhooks/deliveries", handler.WebhookDeliveriesGet(dispatcher))
+	admin.GET("/webhooks/dead-letters", handler.WebhookDeadLettersGet(dispatcher))
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return New(srv.URL, WithToken(adminToken), WithRetries(0, 0)), feed
+}
+
+const adminToken = "admin-s3cret"
+
+func TestPing(t *testing.T) {
+	c, _ := newServer(t)
+	if err := c.Ping(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddAndListItems(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	if err := c.AddItem(ctx, AddItemRequest{Title: "Hello", Post: "World", TTL: "1h"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddItem(ctx, AddItemRequest{Title: "Outage", Post: "Login is down"}); err != nil {
+		t.Fatal(err)
+	}
+
+	items, total, err := c.ListItems(ctx, ListOptions{Query: "login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(items) != 1 || items[0].Title != "Outage" {
+		t.Errorf("ListItems() = %v, %d", items, total)
+	}
+}
+
+func TestAddItemDecodesProblem(t *testing.T) {
+	c, _ := newServer(t)
+
+	err := c.AddItem(context.Background(), AddItemRequest{Title: "x", TTL: "soon"})
+
+	apiErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("AddItem() error = %#v, want *Error", err)
+	}
+	if apiErr.Status != http.StatusBadRequest || apiErr.Detail == "" || !IsBadRequest(err) {
+		t.Errorf("Unexpected error: %+v", apiErr)
+	}
+}
+
+func TestItemsIteratesAllPages(t *testing.T) {
+	c, feed := newServer(t)
+	for i := 0; i < 7; i++ {
+		feed.Add(context.Background(), newsfeed.Item{Title: string(rune('a' + i))})
+	}
+
+	it := c.Items(context.Background(), "", 3)
+	var titles string
+	for it.Next() {
+		titles += it.Item().Title
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if titles != "abcdefg" {
+		t.Errorf("Iterated %q, want abcdefg", titles)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	sub, err := c.CreateWebhook(ctx, CreateWebhookRequest{URL: "http://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Secret == "" {
+		t.Errorf("CreateWebhook did not return the secret")
+	}
+
+	subs, err := c.Webhooks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 || subs[0].Secret != "" {
+		t.Errorf("Webhooks() = %+v", subs)
+	}
+
+	if err := c.DeleteWebhook(ctx, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteWebhook(ctx, sub.ID); !IsNotFound(err) {
+		t.Errorf("Second DeleteWebhook() = %v, want not found", err)
+	}
+
+	if _, err := c.WebhookDeliveries(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.WebhookDeadLetters(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebhooksRequireToken(t *testing.T) {
+	c, _ := newServer(t)
+	anonymous := New(c.baseURL, WithRetries(0, 0))
+
+	_, err := anonymous.CreateWebhook(context.Background(), CreateWebhookRequest{URL: "http://example.com/hook"})
+	if apiErr, ok := err.(*Error); !ok || apiErr.Status != http.StatusUnauthorized {
+		t.Errorf("CreateWebhook() without token = %v, want 401", err)
+	}
+}
+
+func TestIdempotentCallsAreRetried(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"hello":"world"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetries(3, time.Millisecond))
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("Server saw %d calls, want 3", calls)
+	}
+}
+
+func TestPostIsNotRetried(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetries(3, time.Millisecond))
+	err := c.AddItem(context.Background(), AddItemRequest{Title: "x"})
+	if apiErr, ok := err.(*Error); !ok || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("AddItem() error = %v, want a 503 *Error", err)
+	}
+	if calls != 1 {
+		t.Errorf("Server saw %d calls, want 1", calls)
+	}
+}
+
+func TestAddItemWithIdempotencyKeyIsRetried(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Idempotency-Key") != "k1" {
+			t.Errorf("Idempotency-Key = %q", r.Header.Get("Idempotency-Key"))
+		}
+		if atomic.AddInt32(&calls, 1) < 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetries(3, time.Millisecond))
+	if err := c.AddItem(context.Background(), AddItemRequest{Title: "x", IdempotencyKey: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("Server saw %d calls, want 2", calls)
+	}
+}
+
+func TestAddItemIdempotencyKeyAgainstServer(t *testing.T) {
+	c, feed := newServer(t)
+	ctx := context.Background()
+	req := AddItemRequest{Title: "Once", IdempotencyKey: "k1"}
+
+	for i := 0; i < 3; i++ {
+		if err := c.AddItem(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(feed.GetAll(ctx)); n != 1 {
+		t.Errorf("Feed holds %d items, want 1", n)
+	}
+}
+
+func TestTrending(t *testing.T) {
+	c, feed := newServer(t)
+	ctx := context.Background()
+
+	first := feed.Add(ctx, newsfeed.Item{Title: "First"})
+	feed.Add(ctx, newsfeed.Item{Title: "Second"})
+	for i := 0; i < 3; i++ {
+		if err := c.Engage(ctx, first.ID, newsfeed.EngagementReaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	items, _, err := c.ListItems(ctx, ListOptions{Sort: "trending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].ID != first.ID {
+		t.Errorf("trending = %+v", items)
+	}
+
+	if err := c.Engage(ctx, "missing", newsfeed.EngagementView); !IsNotFound(err) {
+		t.Errorf("Engage(missing) err = %v, want 404", err)
+	}
+	if _, _, err := c.ListItems(ctx, ListOptions{Sort: "random"}); !IsBadRequest(err) {
+		t.Errorf("sort=random err = %v, want 400", err)
+	}
+}
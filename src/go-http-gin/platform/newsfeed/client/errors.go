@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// Error is a problem+json response (RFC 7807) from the server. Responses
+// with another content type are reported with only Status and Title set.
+type Error struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("newsfeed: %d %s: %s", e.Status, e.Title, e.Detail)
+	}
+	return fmt.Sprintf("newsfeed: %d %s", e.Status, e.Title)
+}
+
+// IsNotFound reports whether err is a 404 from the server.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsBadRequest reports whether the server rejected the request as invalid.
+func IsBadRequest(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
+
+func decodeError(resp *http.Response, content []byte) error {
+	apiErr := &Error{}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/problem+json" {
+		json.Unmarshal(content, apiErr)
+	}
+	if apiErr.Status == 0 {
+		apiErr.Status = resp.StatusCode
+	}
+	if apiErr.Title == "" {
+		apiErr.Title = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed idempotent call may succeed if sent
+// again. 409 is what the server answers while an earlier attempt with the
+// same Idempotency-Key is still running.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Status == http.StatusTooManyRequests ||
+			apiErr.Status == http.StatusConflict ||
+			apiErr.Status >= 500
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Feed describes a named feed. Retention is a Go duration such as "72h".
+type Feed struct {
+	Name       string `json:"name"`
+	Moderation bool   `json:"moderation"`
+	Retention  string `json:"retention,omitempty"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// Feed returns a client whose item, engagement and moderation calls go to
+// the named feed instead of /newsfeed.
+func (c *Client) Feed(name string) *Client {
+	scoped := *c
+	scoped.feed = name
+	return &scoped
+}
+
+func (c *Client) itemsPath() string {
+	if c.feed == "" {
+		return "/newsfeed"
+	}
+	return "/feeds/" + url.PathEscape(c.feed) + "/items"
+}
+
+func (c *Client) moderationPath() string {
+	if c.feed == "" {
+		return "/admin/moderation"
+	}
+	return "/admin/feeds/" + url.PathEscape(c.feed) + "/moderation"
+}
+
+// Feeds calls GET /feeds. Private feeds are only listed for admin tokens.
+func (c *Client) Feeds(ctx context.Context) ([]Feed, error) {
+	var feeds []Feed
+	_, err := c.do(ctx, http.MethodGet, "/feeds", nil, nil, &feeds)
+	return feeds, err
+}
+
+// CreateFeed calls POST /admin/feeds.
+func (c *Client) CreateFeed(ctx context.Context, feed Feed) (Feed, error) {
+	var created Feed
+	_, err := c.do(ctx, http.MethodPost, "/admin/feeds", nil, feed, &created)
+	return created, err
+}
+
+// UpdateFeed calls PUT /admin/feeds/:feed, replacing every setting.
+func (c *Client) UpdateFeed(ctx context.Context, feed Feed) (Feed, error) {
+	var updated Feed
+	body := feed
+	body.Name = ""
+	_, err := c.do(ctx, http.MethodPut, "/admin/feeds/"+url.PathEscape(feed.Name), nil, body, &updated)
+	return updated, err
+}
+
+// DeleteFeed calls DELETE /admin/feeds/:feed.
+func (c *Client) DeleteFeed(ctx context.Context, name string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/admin/feeds/"+url.PathEscape(name), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"newsfeeder/httpd/handler"
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFeedsServer runs the /feeds routes and returns an admin client and an
+// anonymous one.
+func newFeedsServer(t *testing.T) (admin, anon *Client) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	feeds := newsfeed.NewRegistry(ctx)
+
+	r := gin.New()
+	r.GET("/feeds", handler.FeedsGet(feeds, adminToken))
+	feed := r.Group("/feeds/:feed", handler.FeedAccess(feeds, adminToken))
+	feed.GET("/items", handler.FeedItemsGet())
+	feed.POST("/items", handler.FeedItemsPost(nil, nil))
+	feed.POST("/items/:id/engagement", handler.FeedEngagementPost())
+	a := r.Group("/admin", handler.RequireToken(adminToken))
+	a.POST("/feeds", handler.FeedPost(feeds))
+	a.PUT("/feeds/:feed", handler.FeedPut(feeds))
+	a.DELETE("/feeds/:feed", handler.FeedDelete(feeds))
+	adminFeed := a.Group("/feeds/:feed", handler.FeedAccess(feeds, adminToken))
+	adminFeed.GET("/moderation", handler.FeedModerationGet())
+	adminFeed.POST("/moderation/:id/approve", handler.FeedModerationApprovePost())
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return New(srv.URL, WithToken(adminToken), WithRetries(0, 0)), New(srv.URL, WithRetries(0, 0))
+}
+
+func TestFeeds(t *testing.T) {
+	admin, anon := newFeedsServer(t)
+	ctx := context.Background()
+
+	ops, err := admin.CreateFeed(ctx, Feed{Name: "ops", Moderation: true, Retention: "72h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops.Visibility != "public" || ops.Retention != "72h0m0s" {
+		t.Errorf("created feed = %+v", ops)
+	}
+	if _, err := admin.CreateFeed(ctx, Feed{Name: "ops"}); err == nil {
+		t.Error("CreateFeed accepted a duplicate name")
+	}
+	if _, err := admin.CreateFeed(ctx, Feed{Name: "eng", Visibility: "private"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := anon.CreateFeed(ctx, Feed{Name: "mine"}); err == nil {
+		t.Error("CreateFeed succeeded without a token")
+	}
+
+	// Moderated feed: the post waits in the ops queue only.
+	if err := anon.Feed("ops").AddItem(ctx, AddItemRequest{Title: "Deploy", Post: "v2"}); err != nil {
+		t.Fatal(err)
+	}
+	queue, err := admin.Feed("ops").ModerationQueue(ctx, "")
+	if err != nil || len(queue) != 1 {
+		t.Fatalf("ops queue = %v, %v", queue, err)
+	}
+	if !queue[0].ExpiresAt.Equal(queue[0].CreatedAt.Add(72 * time.Hour)) {
+		t.Errorf("retention not applied: %+v", queue[0])
+	}
+	if _, err := admin.Feed("ops").Approve(ctx, queue[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	items, _, err := anon.Feed("ops").ListItems(ctx, ListOptions{})
+	if err != nil || len(items) != 1 {
+		t.Errorf("ops items = %v, %v", items, err)
+	}
+	if err := anon.Feed("ops").Engage(ctx, items[0].ID, newsfeed.EngagementView); err != nil {
+		t.Error(err)
+	}
+
+	// Private feeds are hidden from anonymous callers.
+	if _, _, err := anon.Feed("eng").ListItems(ctx, ListOptions{}); !IsNotFound(err) {
+		t.Errorf("anonymous read of private feed err = %v, want 404", err)
+	}
+	if err := admin.Feed("eng").AddItem(ctx, AddItemRequest{Title: "RFC"}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := anon.Feeds(ctx); len(list) != 1 || list[0].Name != "ops" {
+		t.Errorf("anonymous feeds = %+v", list)
+	}
+	if list, _ := admin.Feeds(ctx); len(list) != 2 {
+		t.Errorf("admin feeds = %+v", list)
+	}
+
+	if _, err := admin.UpdateFeed(ctx, Feed{Name: "eng"}); err != nil {
+		t.Fatal(err)
+	}
+	if items, _, err := anon.Feed("eng").ListItems(ctx, ListOptions{}); err != nil || len(items) != 1 {
+		t.Errorf("eng items after making it public = %v, %v", items, err)
+	}
+
+	if err := admin.DeleteFeed(ctx, "eng"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := anon.Feed("eng").ListItems(ctx, ListOptions{}); !IsNotFound(err) {
+		t.Errorf("deleted feed err = %v, want 404", err)
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+// UploadMedia calls POST /media with a single file. Uploads are not retried.
+func (c *Client) UploadMedia(ctx context.Context, filename string, r io.Reader) (newsfeed.Attachment, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	if err := form.Close(); err != nil {
+		return newsfeed.Attachment{}, err
+	}
+
+	var attachments []newsfeed.Attachment
+	header := http.Header{"Content-Type": {form.FormDataContentType()}}
+	if _, err := c.send(ctx, http.MethodPost, c.baseURL+"/media", header, body.Bytes(), &attachments); err != nil {
+		return newsfeed.Attachment{}, err
+	}
+	return attachments[0], nil
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUploadMediaAndAttach(t *testing.T) {
+	c, _ := newServer(t)
+	ctx := context.Background()
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 600, 300)))
+	a, err := c.UploadMedia(ctx, "banner.png", &img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ContentType != "image/png" || a.Width != 600 || a.Height != 300 || a.ThumbnailURL == "" {
+		t.Fatalf("attachment = %+v", a)
+	}
+
+	resp, err := http.Get(c.baseURL + a.ThumbnailURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, _, err := image.DecodeConfig(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Width != 256 || thumb.Height != 128 {
+		t.Errorf("thumbnail is %dx%d, want 256x128", thumb.Width, thumb.Height)
+	}
+
+	if err := c.AddItem(ctx, AddItemRequest{Title: "Hi", Post: "With a banner", Attachments: []string{a.ID}}); err != nil {
+		t.Fatal(err)
+	}
+	items, _, err := c.ListItems(ctx, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || len(items[0].Attachments) != 1 || items[0].Attachments[0].ID != a.ID {
+		t.Errorf("items = %+v", items)
+	}
+
+	err = c.AddItem(ctx, AddItemRequest{Title: "Hi", Post: "Missing", Attachments: []string{"nope"}})
+	if !IsBadRequest(err) {
+		t.Errorf("unknown attachment: err = %v, want 400", err)
+	}
+}
+
+func TestUploadMediaRejectsUnsupportedType(t *testing.T) {
+	c, _ := newServer(t)
+
+	_, err := c.UploadMedia(context.Background(), "run.sh", strings.NewReader("\x7fELF\x02\x01\x01"))
+	var e *Error
+	if !errors.As(err, &e) || e.Status != http.StatusUnsupportedMediaType {
+		t.Errorf("err = %v, want 415", err)
+	}
+}
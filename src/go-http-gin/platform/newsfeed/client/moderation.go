@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+// ModerationQueue calls GET /admin/moderation. status is "pending",
+// "rejected" or "all"; empty means pending.
+func (c *Client) ModerationQueue(ctx context.Context, status string) ([]newsfeed.Item, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	var items []newsfeed.Item
+	_, err := c.do(ctx, http.MethodGet, c.moderationPath(), query, nil, &items)
+	return items, err
+}
+
+// Approve calls POST /admin/moderation/:id/approve. It is not retried.
+func (c *Client) Approve(ctx context.Context, id string) (newsfeed.Item, error) {
+	var item newsfeed.Item
+	_, err := c.do(ctx, http.MethodPost, c.moderationPath()+"/"+url.PathEscape(id)+"/approve", nil, nil, &item)
+	return item, err
+}
+
+// Reject calls POST /admin/moderation/:id/reject. It is not retried.
+func (c *Client) Reject(ctx context.Context, id, note string) (newsfeed.Item, error) {
+	var item newsfeed.Item
+	body := struct {
+		Note string `json:"note,omitempty"`
+	}{note}
+	_, err := c.do(ctx, http.MethodPost, c.moderationPath()+"/"+url.PathEscape(id)+"/reject", nil, body, &item)
+	return item, err
+}
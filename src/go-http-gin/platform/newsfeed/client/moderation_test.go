@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+func TestModeration(t *testing.T) {
+	c, feed := newServer(t, newsfeed.WithModeration(true))
+	ctx := context.Background()
+	keep := feed.Add(ctx, newsfeed.Item{Title: "Keep"})
+	spam := feed.Add(ctx, newsfeed.Item{Title: "Spam"})
+
+	queue, err := c.ModerationQueue(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 2 {
+		t.Fatalf("ModerationQueue() = %v, want both items", queue)
+	}
+
+	if _, err := c.Approve(ctx, keep.ID); err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := c.Reject(ctx, spam.ID, "advertising")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != newsfeed.StatusRejected || rejected.ModerationNote != "advertising" {
+		t.Errorf("Reject() = %+v", rejected)
+	}
+
+	items, _, err := c.ListItems(ctx, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ID != keep.ID {
+		t.Errorf("ListItems() = %v, want only the approved item", items)
+	}
+	if _, err := c.Approve(ctx, "missing"); !IsNotFound(err) {
+		t.Errorf("Approve() of unknown item = %v, want not found", err)
+	}
+}
+
+func TestModerationRequiresToken(t *testing.T) {
+	c, _ := newServer(t)
+	anonymous := New(c.baseURL, WithRetries(0, 0))
+
+	_, err := anonymous.ModerationQueue(context.Background(), "")
+	if apiErr, ok := err.(*Error); !ok || apiErr.Status != http.StatusUnauthorized {
+		t.Errorf("ModerationQueue() without token = %v, want 401", err)
+	}
+}
+
+func TestAddItemIsAcceptedForModeration(t *testing.T) {
+	c, feed := newServer(t, newsfeed.WithModeration(true))
+
+	if err := c.AddItem(context.Background(), AddItemRequest{Title: "Review me"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Queue(context.Background(), newsfeed.StatusPending)) != 1 {
+		t.Errorf("Item was not queued for moderation")
+	}
+}
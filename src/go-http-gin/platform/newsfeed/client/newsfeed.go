@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+// AddItemRequest is the body of POST /newsfeed. At most one of ExpiresAt
+// and TTL may be set.
+type AddItemRequest struct {
+	Title     string     `json:"title"`
+	Post      string     `json:"post"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	TTL       string     `json:"ttl,omitempty"`
+	// Attachments are IDs returned by UploadMedia.
+	Attachments []string `json:"attachments,omitempty"`
+
+	// IdempotencyKey, if set, is sent as the Idempotency-Key header and
+	// makes the call safe to retry.
+	IdempotencyKey string `json:"-"`
+}
+
+// ListOptions narrows GET /newsfeed.
+type ListOptions struct {
+	// Query keeps items whose title or post contains it.
+	Query string
+	// Sort is "recent", the default, or "trending".
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+// Ping calls GET /ping.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodGet, "/ping", nil, nil, nil)
+	return err
+}
+
+// AddItem calls POST /newsfeed. It is only retried if the request has an
+// IdempotencyKey.
+func (c *Client) AddItem(ctx context.Context, req AddItemRequest) error {
+	if req.IdempotencyKey == "" {
+		_, err := c.do(ctx, http.MethodPost, c.itemsPath(), nil, req, nil)
+		return err
+	}
+	header := http.Header{"Idempotency-Key": {req.IdempotencyKey}}
+	_, err := c.doWithHeader(ctx, http.MethodPost, c.itemsPath(), nil, header, true, req, nil)
+	return err
+}
+
+// ListItems calls GET /newsfeed and returns one page of items along with the
+// total number of matching items.
+func (c *Client) ListItems(ctx context.Context, opts ListOptions) ([]newsfeed.Item, int, error) {
+	query := url.Values{}
+	if opts.Query != "" {
+		query.Set("q", opts.Query)
+	}
+	if opts.Sort != "" {
+		query.Set("sort", opts.Sort)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var items []newsfeed.Item
+	header, err := c.do(ctx, http.MethodGet, c.itemsPath(), query, nil, &items)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := strconv.Atoi(header.Get("X-Total-Count"))
+	if err != nil {
+		total = opts.Offset + len(items)
+	}
+	return items, total, nil
+}
+
+// Engage calls POST /newsfeed/:id/engagement.
+func (c *Client) Engage(ctx context.Context, id string, kind newsfeed.Engagement) error {
+	body := struct {
+		Kind newsfeed.Engagement `json:"kind"`
+	}{kind}
+	_, err := c.do(ctx, http.MethodPost, c.itemsPath()+"/"+url.PathEscape(id)+"/engagement", nil, body, nil)
+	return err
+}
+
+// ItemIterator walks the feed a page at a time:
+//
+//	it := c.Items(ctx, "", 100)
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ItemIterator struct {
+	ctx    context.Context
+	client *Client
+	opts   ListOptions
+
+	page []newsfeed.Item
+	item newsfeed.Item
+	done bool
+	err  error
+}
+
+// Items iterates over every item matching query, fetching pageSize items per
+// request.
+func (c *Client) Items(ctx context.Context, query string, pageSize int) *ItemIterator {
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	return &ItemIterator{
+		ctx:    ctx,
+		client: c,
+		opts:   ListOptions{Query: query, Limit: pageSize},
+	}
+}
+
+// Next advances to the next item, fetching a new page when needed. It
+// returns false at the end of the feed or on error.
+func (it *ItemIterator) Next() bool {
+	if len(it.page) == 0 && !it.done && it.err == nil {
+		page, total, err := it.client.ListItems(it.ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = page
+		it.opts.Offset += len(page)
+		it.done = len(page) < it.opts.Limit || it.opts.Offset >= total
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.item, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Item returns the current item.
+func (it *ItemIterator) Item() newsfeed.Item {
+	return it.item
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ItemIterator) Err() error {
+	return it.err
+}
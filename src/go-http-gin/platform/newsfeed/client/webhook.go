@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"newsfeeder/platform/webhook"
+)
+
+// CreateWebhookRequest is the body of POST /admin/webhooks. The server
+// generates a secret if none is given and subscribes to item.created by
+// default.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// CreateWebhook calls POST /admin/webhooks. The returned subscription is the
+// only place the secret is reported. It is not retried.
+func (c *Client) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (webhook.Subscription, error) {
+	var sub webhook.Subscription
+	_, err := c.do(ctx, http.MethodPost, "/admin/webhooks", nil, req, &sub)
+	return sub, err
+}
+
+// Webhooks calls GET /admin/webhooks.
+func (c *Client) Webhooks(ctx context.Context) ([]webhook.Subscription, error) {
+	var subs []webhook.Subscription
+	_, err := c.do(ctx, http.MethodGet, "/admin/webhooks", nil, nil, &subs)
+	return subs, err
+}
+
+// DeleteWebhook calls DELETE /admin/webhooks/:id.
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/admin/webhooks/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// WebhookDeliveries calls GET /admin/webhooks/deliveries.
+func (c *Client) WebhookDeliveries(ctx context.Context) ([]webhook.Delivery, error) {
+	var deliveries []webhook.Delivery
+	_, err := c.do(ctx, http.MethodGet, "/admin/webhooks/deliveries", nil, nil, &deliveries)
+	return deliveries, err
+}
+
+// WebhookDeadLetters calls GET /admin/webhooks/dead-letters.
+func (c *Client) WebhookDeadLetters(ctx context.Context) ([]webhook.DeadLetter, error) {
+	var dead []webhook.DeadLetter
+	_, err := c.do(ctx, http.MethodGet, "/admin/webhooks/dead-letters", nil, nil, &dead)
+	return dead, err
+}
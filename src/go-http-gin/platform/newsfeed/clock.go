@@ -0,0 +1,21 @@
+package newsfeed
+
+import "time"
+
+// Clock tells the feed what time it is and drives its background workers.
+type Clock interface {
+	Now() time.Time
+	// Tick delivers the current time every d until stop is called.
+	Tick(d time.Duration) (ticks <-chan time.Time, stop func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Tick(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
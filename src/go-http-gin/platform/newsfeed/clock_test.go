@@ -0,0 +1,61 @@
+package newsfeed
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeClock only moves when Advance is called, firing every ticker once.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []chan time.Time
+	ticking chan struct{}
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{
+		now:     now,
+		ticking: make(chan struct{}, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Tick(d time.Duration) (<-chan time.Time, func()) {
+	ch := make(chan time.Time)
+
+	c.mu.Lock()
+	c.tickers = append(c.tickers, ch)
+	c.mu.Unlock()
+	c.ticking <- struct{}{}
+
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, t := range c.tickers {
+			if t == ch {
+				c.tickers = append(c.tickers[:i], c.tickers[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Advance moves the clock forward and blocks until every ticker has
+// received the new time.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	tickers := append([]chan time.Time(nil), c.tickers...)
+	c.mu.Unlock()
+
+	for _, ch := range tickers {
+		ch <- now
+	}
+}
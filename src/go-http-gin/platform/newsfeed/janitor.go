@@ -0,0 +1,64 @@
+package newsfeed
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// JanitorStats counts the work done by a Janitor.
+type JanitorStats struct {
+	Runs       int64     `json:"runs"`
+	Purged     int64     `json:"purged"`
+	LastPurged int       `json:"last_purged"`
+	LastRun    time.Time `json:"last_run,omitzero"`
+}
+
+// Janitor periodically purges expired items from the store.
+type Janitor struct {
+	repo     *Repo
+	interval time.Duration
+
+	mu    sync.Mutex
+	stats JanitorStats
+}
+
+func NewJanitor(repo *Repo, interval time.Duration) *Janitor {
+	return &Janitor{
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+// Run purges expired items every interval until ctx is cancelled.
+func (j *Janitor) Run(ctx context.Context) {
+	ticks, stop := j.repo.clock.Tick(j.interval)
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticks:
+			j.sweep(now)
+		}
+	}
+}
+
+// Stats returns a snapshot of the purge counters.
+func (j *Janitor) Stats() JanitorStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.stats
+}
+
+func (j *Janitor) sweep(now time.Time) {
+	purged := j.repo.PurgeExpired()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stats.Runs++
+	j.stats.Purged += int64(purged)
+	j.stats.LastPurged = purged
+	j.stats.LastRun = now
+}
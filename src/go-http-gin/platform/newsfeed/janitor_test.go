@@ -0,0 +1,88 @@
+package newsfeed
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetAllHidesExpiredItems(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	feed.Add(context.Background(), Item{Title: "Outage", ExpiresAt: epoch.Add(time.Minute)})
+	feed.Add(context.Background(), Item{Title: "Forever"})
+
+	clock.Advance(time.Minute)
+
+	results := feed.GetAll(context.Background())
+	if len(results) != 1 || results[0].Title != "Forever" {
+		t.Errorf("GetAll() = %v, want only Forever", results)
+	}
+	if len(feed.Items) != 2 {
+		t.Errorf("Expired item was removed before purge")
+	}
+}
+
+func TestPublishDueDropsExpiredItems(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	feed.Add(context.Background(), Item{
+		Title:     "Missed",
+		PublishAt: epoch.Add(time.Minute),
+		ExpiresAt: epoch.Add(2 * time.Minute),
+	})
+
+	clock.Advance(time.Hour)
+
+	if due := feed.PublishDue(); len(due) != 0 {
+		t.Errorf("PublishDue() = %v, want nothing", due)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	feed.Add(context.Background(), Item{Title: "Outage", ExpiresAt: epoch.Add(time.Minute)})
+	feed.Add(context.Background(), Item{Title: "Later", PublishAt: epoch.Add(time.Hour), ExpiresAt: epoch.Add(time.Minute)})
+	feed.Add(context.Background(), Item{Title: "Forever"})
+
+	clock.Advance(time.Minute)
+
+	if purged := feed.PurgeExpired(); purged != 2 {
+		t.Errorf("PurgeExpired() = %d, want 2", purged)
+	}
+	if len(feed.Items) != 1 || len(feed.Scheduled()) != 0 {
+		t.Errorf("Expired items are still stored")
+	}
+}
+
+func TestJanitorRecordsPurgedCounts(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	feed.Add(context.Background(), Item{Title: "Outage", ExpiresAt: epoch.Add(time.Minute)})
+	feed.Add(context.Background(), Item{Title: "Notice", ExpiresAt: epoch.Add(time.Minute)})
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	janitor := NewJanitor(feed, time.Minute)
+	go janitor.Run(ctx)
+	<-clock.ticking
+
+	clock.Advance(time.Minute)
+	clock.Advance(time.Minute)
+
+	// The second tick is only received once the first sweep has finished,
+	// so wait for it to be recorded as well.
+	deadline := time.Now().Add(time.Second)
+	for janitor.Stats().Runs < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := janitor.Stats()
+	if stats.Runs != 2 || stats.Purged != 2 || stats.LastPurged != 0 {
+		t.Errorf("Stats() = %+v, want 2 runs and 2 purged", stats)
+	}
+	if len(feed.Items) != 0 {
+		t.Errorf("Expired items were not purged")
+	}
+}
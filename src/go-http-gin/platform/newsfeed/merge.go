@@ -0,0 +1,107 @@
+package newsfeed
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Replicated is implemented by stores whose feeds can be copied between
+// instances. Items are keyed by the name of their feed.
+type Replicated interface {
+	Export(ctx context.Context) map[string][]Item
+	Merge(ctx context.Context, feeds map[string][]Item) map[string][]Item
+}
+
+// Export returns the replicable items of every feed in the registry.
+func (r *Registry) Export(ctx context.Context) map[string][]Item {
+	feeds := map[string][]Item{}
+	for _, f := range r.List() {
+		feeds[f.Name] = f.Repo.Export(ctx)
+	}
+	return feeds
+}
+
+// Merge merges items copied from another instance into the feeds of the
+// same name, and returns the items added to each. Feeds are not created:
+// items for a feed this registry does not host are skipped.
+func (r *Registry) Merge(ctx context.Context, feeds map[string][]Item) map[string][]Item {
+	added := map[string][]Item{}
+	for name, items := range feeds {
+		f, err := r.Get(name)
+		if err != nil {
+			continue
+		}
+		if items := f.Repo.Merge(ctx, items); len(items) > 0 {
+			added[name] = items
+		}
+	}
+	return added
+}
+
+// Export returns every unexpired approved item, published or scheduled.
+func (r *Repo) Export(ctx context.Context) []Item {
+	_, span := tracer().Start(ctx, "Repo.Export")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.clock.Now()
+	items := append(unexpired(r.Items, now), unexpired(r.scheduled, now)...)
+	span.SetAttributes(attribute.Int("newsfeed.items", len(items)))
+	return items
+}
+
+// Merge adds items copied from another instance, keeping their IDs,
+// timestamps and status. Items that are already known or have expired are
+// skipped, which makes merging idempotent and independent of order: the
+// feed behaves as a grow-only set keyed by ID. It returns the items added.
+func (r *Repo) Merge(ctx context.Context, items []Item) []Item {
+	_, span := tracer().Start(ctx, "Repo.Merge")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	var added []Item
+	for _, item := range items {
+		if item.ID == "" || item.Expired(now) || r.known(item.ID) {
+			continue
+		}
+		if item.Status == "" {
+			item.Status = StatusApproved
+		}
+		if item.Status != StatusApproved {
+			r.queue = append(r.queue, item)
+		} else {
+			r.place(item, now)
+		}
+		added = append(added, item)
+	}
+	span.SetAttributes(
+		attribute.Int("newsfeed.received", len(items)),
+		attribute.Int("newsfeed.added", len(added)),
+	)
+	return added
+}
+
+// known reports whether an item with this ID is held anywhere in the repo.
+// It must be called with r.mu held.
+func (r *Repo) known(id string) bool {
+	if _, ok := r.trend.byID[id]; ok {
+		return true
+	}
+	for _, item := range r.scheduled {
+		if item.ID == id {
+			return true
+		}
+	}
+	for _, item := range r.queue {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package newsfeed
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	clock := newFakeClock(epoch)
+	source := New(WithClock(clock))
+	ctx := context.Background()
+
+	source.Add(ctx, Item{Title: "Now"})
+	source.Add(ctx, Item{Title: "Later", PublishAt: epoch.Add(time.Hour)})
+	exported := source.Export(ctx)
+	if len(exported) != 2 {
+		t.Fatalf("Export = %v", exported)
+	}
+	expired := Item{ID: "gone", Title: "Gone", ExpiresAt: epoch}
+
+	// Merging in either order, repeatedly, gives the same feed.
+	forward := New(WithClock(clock))
+	forward.Merge(ctx, exported)
+	forward.Merge(ctx, append(exported, expired))
+	backward := New(WithClock(clock))
+	backward.Merge(ctx, []Item{exported[1], expired, exported[0]})
+
+	for _, feed := range []*Repo{forward, backward} {
+		items := feed.GetAll(ctx)
+		if len(items) != 1 || items[0].ID != exported[0].ID || !items[0].CreatedAt.Equal(epoch) {
+			t.Errorf("published = %v", items)
+		}
+		if s := feed.Scheduled(); len(s) != 1 || s[0].ID != exported[1].ID {
+			t.Errorf("scheduled = %v", s)
+		}
+	}
+
+	if added := forward.Merge(ctx, exported); len(added) != 0 {
+		t.Errorf("re-merge added %v", added)
+	}
+}
+
+func TestMergeOrdersByPublicationTime(t *testing.T) {
+	clock := newFakeClock(epoch.Add(2 * time.Hour))
+	feed := New(WithClock(clock))
+	ctx := context.Background()
+
+	feed.Add(ctx, Item{Title: "Local"})
+	feed.Merge(ctx, []Item{
+		{ID: "new", Title: "New", CreatedAt: epoch.Add(time.Hour)},
+		{ID: "old", Title: "Old", CreatedAt: epoch},
+		{ID: "scheduled", Title: "Scheduled", CreatedAt: epoch, PublishAt: epoch.Add(90 * time.Minute)},
+	})
+
+	var titles []string
+	for _, item := range feed.GetAll(ctx) {
+		titles = append(titles, item.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Old,New,Scheduled,Local" {
+		t.Errorf("GetAll = %s, want Old,New,Scheduled,Local", got)
+	}
+
+	// Merged items score as of when they were published, not merged.
+	titles = nil
+	for _, item := range feed.Trending(ctx) {
+		titles = append(titles, item.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Local,Scheduled,New,Old" {
+		t.Errorf("Trending = %s, want Local,Scheduled,New,Old", got)
+	}
+}
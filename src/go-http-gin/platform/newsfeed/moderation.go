@@ -0,0 +1,112 @@
+package newsfeed
+
+import (
+	"context"
+	"errors"
+
+	"newsfeeder/platform/logging"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Status is where an item stands in moderation. Only approved items reach
+// the feed.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+)
+
+var ErrNotFound = errors.New("newsfeed item not found")
+
+type Moderator interface {
+	Queue(ctx context.Context, status Status) []Item
+	Approve(ctx context.Context, id string) (Item, error)
+	Reject(ctx context.Context, id, note string) (Item, error)
+}
+
+// WithModeration holds new items as pending until a moderator approves
+// them.
+func WithModeration(enabled bool) Option {
+	return func(r *Repo) {
+		r.moderated = enabled
+	}
+}
+
+// Queue returns the unexpired items awaiting or refused approval, oldest
+// first. An empty status returns both.
+func (r *Repo) Queue(ctx context.Context, status Status) []Item {
+	ctx, span := tracer().Start(ctx, "Repo.Queue")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []Item{}
+	for _, item := range unexpired(r.queue, r.clock.Now()) {
+		if status == "" || item.Status == status {
+			items = append(items, item)
+		}
+	}
+	span.SetAttributes(attribute.Int("newsfeed.items", len(items)))
+	return items
+}
+
+// Approve takes a pending or rejected item out of the queue and schedules
+// or publishes it.
+func (r *Repo) Approve(ctx context.Context, id string) (Item, error) {
+	ctx, span := tracer().Start(ctx, "Repo.Approve")
+	defer span.End()
+	span.SetAttributes(attribute.String("newsfeed.item_id", id))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.queued(id)
+	if i < 0 {
+		return Item{}, ErrNotFound
+	}
+	item := r.queue[i]
+	r.queue = append(r.queue[:i], r.queue[i+1:]...)
+	item.Status = StatusApproved
+	item.ModerationNote = ""
+
+	logging.FromContext(ctx).InfoContext(ctx, "newsfeed item approved", "item_id", id)
+	r.place(item, r.clock.Now())
+	return item, nil
+}
+
+// Reject marks a queued item as rejected. It stays in the queue so that the
+// decision can be reviewed or reversed.
+func (r *Repo) Reject(ctx context.Context, id, note string) (Item, error) {
+	ctx, span := tracer().Start(ctx, "Repo.Reject")
+	defer span.End()
+	span.SetAttributes(attribute.String("newsfeed.item_id", id))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.queued(id)
+	if i < 0 {
+		return Item{}, ErrNotFound
+	}
+	r.queue[i].Status = StatusRejected
+	r.queue[i].ModerationNote = note
+
+	logging.FromContext(ctx).InfoContext(ctx, "newsfeed item rejected", "item_id", id)
+	return r.queue[i], nil
+}
+
+// queued returns the index of id in the moderation queue, or -1. It must be
+// called with r.mu held.
+func (r *Repo) queued(id string) int {
+	now := r.clock.Now()
+	for i, item := range r.queue {
+		if item.ID == id && !item.Expired(now) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package newsfeed
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestModeratedItemsArePending(t *testing.T) {
+	feed := New(WithModeration(true))
+	items, cancel := feed.Subscribe()
+	defer cancel()
+
+	item := feed.Add(context.Background(), Item{Title: "Needs review"})
+
+	if item.Status != StatusPending {
+		t.Errorf("Status = %q, want pending", item.Status)
+	}
+	if len(feed.GetAll(context.Background())) != 0 {
+		t.Errorf("Pending item is visible")
+	}
+	if queue := feed.Queue(context.Background(), StatusPending); len(queue) != 1 {
+		t.Errorf("Queue() = %v, want the pending item", queue)
+	}
+	select {
+	case <-items:
+		t.Errorf("Subscribers were notified of a pending item")
+	default:
+	}
+}
+
+func TestUnmoderatedItemsAreApproved(t *testing.T) {
+	feed := New()
+	item := feed.Add(context.Background(), Item{Title: "Straight in"})
+	if item.Status != StatusApproved {
+		t.Errorf("Status = %q, want approved", item.Status)
+	}
+}
+
+func TestApprovePublishes(t *testing.T) {
+	ctx := context.Background()
+	feed := New(WithModeration(true))
+	item := feed.Add(ctx, Item{Title: "Needs review"})
+	items, cancel := feed.Subscribe()
+	defer cancel()
+
+	approved, err := feed.Approve(ctx, item.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if approved.Status != StatusApproved {
+		t.Errorf("Status = %q, want approved", approved.Status)
+	}
+	if results := feed.GetAll(ctx); len(results) != 1 || results[0].ID != item.ID {
+		t.Errorf("GetAll() = %v, want the approved item", results)
+	}
+	if len(feed.Queue(ctx, "")) != 0 {
+		t.Errorf("Approved item is still queued")
+	}
+	select {
+	case got := <-items:
+		if got.ID != item.ID {
+			t.Errorf("Subscriber got %q, want %q", got.ID, item.ID)
+		}
+	default:
+		t.Errorf("Subscribers were not notified")
+	}
+	if _, err := feed.Approve(ctx, item.ID); err != ErrNotFound {
+		t.Errorf("Second Approve() = %v, want ErrNotFound", err)
+	}
+}
+
+func TestApproveKeepsSchedule(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithModeration(true))
+	item := feed.Add(ctx, Item{Title: "Later", PublishAt: epoch.Add(time.Hour)})
+
+	feed.Approve(ctx, item.ID)
+
+	if len(feed.GetAll(ctx)) != 0 || len(feed.Scheduled()) != 1 {
+		t.Errorf("Approved item was not scheduled")
+	}
+}
+
+func TestRejectKeepsItemOutOfFeed(t *testing.T) {
+	ctx := context.Background()
+	feed := New(WithModeration(true))
+	item := feed.Add(ctx, Item{Title: "Spam"})
+
+	rejected, err := feed.Reject(ctx, item.ID, "advertising")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rejected.Status != StatusRejected || rejected.ModerationNote != "advertising" {
+		t.Errorf("Reject() = %+v", rejected)
+	}
+	if len(feed.GetAll(ctx)) != 0 {
+		t.Errorf("Rejected item is visible")
+	}
+	if len(feed.Queue(ctx, StatusPending)) != 0 || len(feed.Queue(ctx, StatusRejected)) != 1 {
+		t.Errorf("Rejected item is not listed as rejected")
+	}
+	if _, err := feed.Reject(ctx, "missing", ""); err != ErrNotFound {
+		t.Errorf("Reject() of unknown item = %v, want ErrNotFound", err)
+	}
+}
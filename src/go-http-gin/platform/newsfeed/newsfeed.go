@@ -1,32 +1,357 @@
 package newsfeed
 
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"newsfeeder/platform/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func tracer() trace.Tracer {
+	return otel.Tracer("newsfeeder/platform/newsfeed")
+}
+
 type Getter interface {
-	GetAll() []Item
+	GetAll(ctx context.Context) []Item
+	Trending(ctx context.Context) []Item
 }
 
 type Added interface {
-	Add(item Item)
+	Add(ctx context.Context, item Item) Item
+}
+
+type Subscriber interface {
+	Subscribe() (<-chan Item, func())
 }
 
 type Item struct {
-	Title string `json: "title"`
-	Post string `json: "post"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Post      string    `json:"post"`
+	PublishAt time.Time `json:"publish_at,omitzero"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    Status    `json:"status"`
+	// ModerationNote is the moderator's reason for rejecting the item.
+	ModerationNote string `json:"moderation_note,omitempty"`
+	// FilterDecisions lists what the content filters did to the item.
+	FilterDecisions []FilterDecision `json:"filter_decisions,omitempty"`
+	Attachments     []Attachment     `json:"attachments,omitempty"`
+	// TTL, when an item without an ExpiresAt is added, expires it this long
+	// after it is published. Add turns it into ExpiresAt using the repo's
+	// clock.
+	TTL time.Duration `json:"-"`
+}
+
+// Attachment describes an uploaded file referenced by an item.
+type Attachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+	// Width, Height and ThumbnailURL are only set for images.
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// FilterDecision records a content filter acting on an item.
+type FilterDecision struct {
+	Filter string `json:"filter"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SetTTL makes the item expire ttl after it is published, or after now if
+// it is not scheduled.
+func (i *Item) SetTTL(ttl time.Duration, now time.Time) {
+	start := now
+	if i.PublishAt.After(start) {
+		start = i.PublishAt
+	}
+	i.ExpiresAt = start.Add(ttl)
+}
+
+// publishedAt is when the item appeared in the feed: its PublishAt if it
+// was scheduled, otherwise its creation time.
+func (i Item) publishedAt() time.Time {
+	if !i.PublishAt.IsZero() {
+		return i.PublishAt
+	}
+	return i.CreatedAt
+}
+
+// Expired reports whether the item has an expiry time at or before now.
+func (i Item) Expired(now time.Time) bool {
+	return !i.ExpiresAt.IsZero() && !now.Before(i.ExpiresAt)
+}
+
+// Search returns the items whose title or post contains q, ignoring case.
+func Search(items []Item, q string) []Item {
+	q = strings.ToLower(q)
+	found := []Item{}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Title), q) ||
+			strings.Contains(strings.ToLower(item.Post), q) {
+			found = append(found, item)
+		}
+	}
+	return found
 }
 
 type Repo struct {
 	Items []Item
+
+	mu          sync.RWMutex
+	clock       Clock
+	scheduled   []Item
+	queue       []Item
+	moderated   bool
+	retention   time.Duration
+	trend       *trend
+	subscribers map[chan Item]struct{}
+}
+
+// Option configures a Repo created with New.
+type Option func(*Repo)
+
+// WithClock replaces the wall clock used to decide when items are due.
+func WithClock(clock Clock) Option {
+	return func(r *Repo) {
+		r.clock = clock
+	}
+}
+
+// WithRetention expires items that have no expiry of their own retention
+// after they are published.
+func WithRetention(retention time.Duration) Option {
+	return func(r *Repo) {
+		r.retention = retention
+	}
+}
+
+func New(opts ...Option) *Repo {
+	r := &Repo{
+		Items:       []Item{},
+		clock:       realClock{},
+		trend:       newTrend(DefaultHalfLife),
+		subscribers: map[chan Item]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add stores the item, assigning an ID, creation time, moderation status
+// and expiry if it has none, and returns the stored item. Pending items
+// wait in the moderation queue, and items with a PublishAt in the future
+// are held back until PublishDue promotes them. Approved items that have
+// already expired are dropped without being published.
+func (r *Repo) Add(ctx context.Context, item Item) Item {
+	ctx, span := tracer().Start(ctx, "Repo.Add")
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	if item.ID == "" {
+		item.ID = newID()
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+	if item.ExpiresAt.IsZero() {
+		ttl := item.TTL
+		if ttl <= 0 {
+			ttl = r.retention
+		}
+		if ttl > 0 {
+			item.SetTTL(ttl, now)
+		}
+	}
+	item.TTL = 0
+	if item.Status == "" {
+		item.Status = StatusApproved
+		if r.moderated {
+			item.Status = StatusPending
+		}
+	}
+
+	scheduled := item.PublishAt.After(now)
+	span.SetAttributes(
+		attribute.String("newsfeed.item_id", item.ID),
+		attribute.String("newsfeed.status", string(item.Status)),
+		attribute.Bool("newsfeed.scheduled", scheduled),
+	)
+	logging.FromContext(ctx).DebugContext(ctx, "newsfeed add",
+		"item_id", item.ID, "status", item.Status, "scheduled", scheduled)
+
+	if item.Status != StatusApproved {
+		r.queue = append(r.queue, item)
+		return item
+	}
+	r.place(item, now)
+	return item
+}
+
+// GetAll returns the published items that have not expired.
+func (r *Repo) GetAll(ctx context.Context) []Item {
+	ctx, span := tracer().Start(ctx, "Repo.GetAll")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := unexpired(r.Items, r.clock.Now())
+	span.SetAttributes(attribute.Int("newsfeed.items", len(items)))
+	logging.FromContext(ctx).DebugContext(ctx, "newsfeed get all", "items", len(items))
+	return items
 }
 
-func New() *Repo {
-	return &Repo{
-		Items: []Item{},
+// Scheduled returns the items still waiting for their PublishAt time.
+func (r *Repo) Scheduled() []Item {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return unexpired(r.scheduled, r.clock.Now())
+}
+
+// PurgeExpired removes expired items from the store and returns how many
+// were removed.
+func (r *Repo) PurgeExpired() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	before := len(r.Items) + len(r.scheduled) + len(r.queue)
+	r.Items = unexpired(r.Items, now)
+	r.scheduled = unexpired(r.scheduled, now)
+	r.queue = unexpired(r.queue, now)
+	r.trend.removeExpired(now)
+	return before - len(r.Items) - len(r.scheduled) - len(r.queue)
+}
+
+// PublishDue moves every scheduled item whose PublishAt has passed into the
+// feed and returns the promoted items.
+func (r *Repo) PublishDue() []Item {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	var due []Item
+	pending := r.scheduled[:0]
+	for _, item := range r.scheduled {
+		if item.Expired(now) {
+			continue
+		}
+		if item.PublishAt.After(now) {
+			pending = append(pending, item)
+			continue
+		}
+		due = append(due, item)
 	}
+	r.scheduled = pending
+
+	for _, item := range due {
+		r.publish(item)
+	}
+	return due
 }
 
-func (r *Repo) Add(item Item) {
-	r.Items = append(r.Items, item)
+// Subscribe returns a channel receiving every item as it becomes visible in
+// the feed, and a function that ends the subscription. Slow subscribers miss
+// items rather than block the feed.
+func (r *Repo) Subscribe() (<-chan Item, func()) {
+	ch := make(chan Item, 64)
+
+	r.mu.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.subscribers, ch)
+			r.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// configure applies feed settings to the repo.
+func (r *Repo) configure(s Settings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.moderated = s.Moderation
+	r.retention = s.Retention
 }
 
-func (r *Repo) GetAll() []Item {
-	return r.Items
-}
\ No newline at end of file
+func unexpired(items []Item, now time.Time) []Item {
+	live := make([]Item, 0, len(items))
+	for _, item := range items {
+		if !item.Expired(now) {
+			live = append(live, item)
+		}
+	}
+	return live
+}
+
+// place schedules an approved item or publishes it straight away, unless
+// it has already expired. It must be called with r.mu held.
+func (r *Repo) place(item Item, now time.Time) {
+	if item.Expired(now) {
+		return
+	}
+	if item.PublishAt.After(now) {
+		r.scheduled = append(r.scheduled, item)
+		return
+	}
+	r.publish(item)
+}
+
+// publish must be called with r.mu held. The feed is kept in order of
+// publication time rather than arrival, so instances that merge the same
+// items in a different order list them the same way, and an item's
+// trending score starts from when it was published.
+func (r *Repo) publish(item Item) {
+	at := item.publishedAt()
+	i := sort.Search(len(r.Items), func(i int) bool {
+		return r.Items[i].publishedAt().After(at)
+	})
+	r.Items = append(r.Items, Item{})
+	copy(r.Items[i+1:], r.Items[i:])
+	r.Items[i] = item
+
+	if now := r.clock.Now(); at.After(now) {
+		at = now
+	}
+	r.trend.insert(item, at)
+	for ch := range r.subscribers {
+		select {
+		case ch <- item:
+		default:
+		}
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,10 +1,14 @@
 package newsfeed
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+)
 
 func TestAdd(t *testing.T) {
 	feed := New()
-	feed.Add(Item{"An Item", "Demo body"})
+	feed.Add(context.Background(), Item{Title: "An Item", Post: "Demo body"})
 	if len(feed.Items) == 0 {
 		t.Errorf("Item was not added")
 	}
@@ -12,9 +16,67 @@ func TestAdd(t *testing.T) {
 
 func TestGetAll(t *testing.T) {
 	feed := New()
-	feed.Add(Item{})
-	results := feed.GetAll()
+	feed.Add(context.Background(), Item{})
+	results := feed.GetAll(context.Background())
 	if len(results) != 1 {
 		t.Errorf("Item was not added")
 	}
-}
\ No newline at end of file
+}
+
+func TestAddAssignsIDAndCreatedAt(t *testing.T) {
+	feed := New()
+	feed.Add(context.Background(), Item{Title: "First"})
+	feed.Add(context.Background(), Item{Title: "Second"})
+	results := feed.GetAll(context.Background())
+	if results[0].ID == "" || results[0].ID == results[1].ID {
+		t.Errorf("Items were not given unique IDs: %q, %q", results[0].ID, results[1].ID)
+	}
+	if results[0].CreatedAt.IsZero() {
+		t.Errorf("CreatedAt was not set")
+	}
+}
+
+func TestAddAppliesTTLWithRepoClock(t *testing.T) {
+	feed := New(WithClock(newFakeClock(epoch)))
+	ctx := context.Background()
+
+	now := feed.Add(ctx, Item{Title: "Now", TTL: time.Hour})
+	later := feed.Add(ctx, Item{Title: "Later", TTL: time.Hour, PublishAt: epoch.Add(time.Hour)})
+	if !now.ExpiresAt.Equal(epoch.Add(time.Hour)) || now.TTL != 0 {
+		t.Errorf("Now expires at %v, TTL %v", now.ExpiresAt, now.TTL)
+	}
+	if !later.ExpiresAt.Equal(epoch.Add(2 * time.Hour)) {
+		t.Errorf("Later expires at %v", later.ExpiresAt)
+	}
+}
+
+func TestAddDropsExpiredItems(t *testing.T) {
+	feed := New(WithClock(newFakeClock(epoch)))
+	ctx := context.Background()
+	published, unsubscribe := feed.Subscribe()
+	defer unsubscribe()
+
+	feed.Add(ctx, Item{Title: "Stale", ExpiresAt: epoch.Add(-time.Minute)})
+	if items := feed.GetAll(ctx); len(items) != 0 {
+		t.Errorf("GetAll = %v", items)
+	}
+	if n := len(feed.Items); n != 0 {
+		t.Errorf("Feed stored %d expired items", n)
+	}
+	select {
+	case item := <-published:
+		t.Errorf("Subscribers were sent %v", item)
+	default:
+	}
+}
+
+func TestSearch(t *testing.T) {
+	items := []Item{
+		{Title: "Release notes", Post: "Version 2 is out"},
+		{Title: "Outage", Post: "Login is degraded"},
+	}
+	found := Search(items, "LOGIN")
+	if len(found) != 1 || found[0].Title != "Outage" {
+		t.Errorf("Search() = %v, want only Outage", found)
+	}
+}
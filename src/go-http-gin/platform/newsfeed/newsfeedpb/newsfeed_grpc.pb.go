@@ -0,0 +1,219 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: newsfeed.proto
+
+package newsfeedpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Newsfeed_AddItem_FullMethodName     = "/newsfeed.v1.Newsfeed/AddItem"
+	Newsfeed_ListItems_FullMethodName   = "/newsfeed.v1.Newsfeed/ListItems"
+	Newsfeed_StreamItems_FullMethodName = "/newsfeed.v1.Newsfeed/StreamItems"
+)
+
+// NewsfeedClient is the client API for Newsfeed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Newsfeed exposes the default feed, the one served by the /newsfeed HTTP
+// routes. Named feeds are only available over HTTP.
+type NewsfeedClient interface {
+	// AddItem posts to the default feed. Calls must carry the server's admin
+	// token as "authorization: Bearer <token>" metadata. Attachments and
+	// idempotency keys are only supported over HTTP.
+	AddItem(ctx context.Context, in *AddItemRequest, opts ...grpc.CallOption) (*Item, error)
+	ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error)
+	// StreamItems sends every item as it becomes visible in the feed. A
+	// stream that reads too slowly misses items rather than holding up the
+	// feed.
+	StreamItems(ctx context.Context, in *StreamItemsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Item], error)
+}
+
+type newsfeedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNewsfeedClient(cc grpc.ClientConnInterface) NewsfeedClient {
+	return &newsfeedClient{cc}
+}
+
+func (c *newsfeedClient) AddItem(ctx context.Context, in *AddItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Item)
+	err := c.cc.Invoke(ctx, Newsfeed_AddItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfeedClient) ListItems(ctx context.Context, in *ListItemsRequest, opts ...grpc.CallOption) (*ListItemsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListItemsResponse)
+	err := c.cc.Invoke(ctx, Newsfeed_ListItems_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsfeedClient) StreamItems(ctx context.Context, in *StreamItemsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Item], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Newsfeed_ServiceDesc.Streams[0], Newsfeed_StreamItems_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamItemsRequest, Item]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Newsfeed_StreamItemsClient = grpc.ServerStreamingClient[Item]
+
+// NewsfeedServer is the server API for Newsfeed service.
+// All implementations must embed UnimplementedNewsfeedServer
+// for forward compatibility.
+//
+// Newsfeed exposes the default feed, the one served by the /newsfeed HTTP
+// routes. Named feeds are only available over HTTP.
+type NewsfeedServer interface {
+	// AddItem posts to the default feed. Calls must carry the server's admin
+	// token as "authorization: Bearer <token>" metadata. Attachments and
+	// idempotency keys are only supported over HTTP.
+	AddItem(context.Context, *AddItemRequest) (*Item, error)
+	ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error)
+	// StreamItems sends every item as it becomes visible in the feed. A
+	// stream that reads too slowly misses items rather than holding up the
+	// feed.
+	StreamItems(*StreamItemsRequest, grpc.ServerStreamingServer[Item]) error
+	mustEmbedUnimplementedNewsfeedServer()
+}
+
+// UnimplementedNewsfeedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNewsfeedServer struct{}
+
+func (UnimplementedNewsfeedServer) AddItem(context.Context, *AddItemRequest) (*Item, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddItem not implemented")
+}
+func (UnimplementedNewsfeedServer) ListItems(context.Context, *ListItemsRequest) (*ListItemsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListItems not implemented")
+}
+func (UnimplementedNewsfeedServer) StreamItems(*StreamItemsRequest, grpc.ServerStreamingServer[Item]) error {
+	return status.Error(codes.Unimplemented, "method StreamItems not implemented")
+}
+func (UnimplementedNewsfeedServer) mustEmbedUnimplementedNewsfeedServer() {}
+func (UnimplementedNewsfeedServer) testEmbeddedByValue()                  {}
+
+// UnsafeNewsfeedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NewsfeedServer will
+// result in compilation errors.
+type UnsafeNewsfeedServer interface {
+	mustEmbedUnimplementedNewsfeedServer()
+}
+
+func RegisterNewsfeedServer(s grpc.ServiceRegistrar, srv NewsfeedServer) {
+	// If the following call panics, it indicates UnimplementedNewsfeedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Newsfeed_ServiceDesc, srv)
+}
+
+func _Newsfeed_AddItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfeedServer).AddItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfeed_AddItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfeedServer).AddItem(ctx, req.(*AddItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfeed_ListItems_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListItemsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsfeedServer).ListItems(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Newsfeed_ListItems_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsfeedServer).ListItems(ctx, req.(*ListItemsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Newsfeed_StreamItems_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamItemsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NewsfeedServer).StreamItems(m, &grpc.GenericServerStream[StreamItemsRequest, Item]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Newsfeed_StreamItemsServer = grpc.ServerStreamingServer[Item]
+
+// Newsfeed_ServiceDesc is the grpc.ServiceDesc for Newsfeed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Newsfeed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "newsfeed.v1.Newsfeed",
+	HandlerType: (*NewsfeedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddItem",
+			Handler:    _Newsfeed_AddItem_Handler,
+		},
+		{
+			MethodName: "ListItems",
+			Handler:    _Newsfeed_ListItems_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamItems",
+			Handler:       _Newsfeed_StreamItems_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "newsfeed.proto",
+}
@@ -0,0 +1,182 @@
+package newsfeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultFeed is the feed served by the original /newsfeed routes. It
+// cannot be deleted.
+const DefaultFeed = "default"
+
+var (
+	ErrFeedNotFound = errors.New("feed not found")
+	ErrFeedExists   = errors.New("feed already exists")
+)
+
+// Visibility controls who may read and post to a feed.
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private"
+)
+
+// Settings configure a feed.
+type Settings struct {
+	// Moderation holds new items for approval.
+	Moderation bool
+	// Retention, if set, expires items that have no expiry of their own
+	// this long after they are published.
+	Retention time.Duration
+	// Visibility defaults to public.
+	Visibility Visibility
+}
+
+func (s *Settings) validate() error {
+	switch s.Visibility {
+	case "":
+		s.Visibility = VisibilityPublic
+	case VisibilityPublic, VisibilityPrivate:
+	default:
+		return fmt.Errorf("visibility must be %s or %s", VisibilityPublic, VisibilityPrivate)
+	}
+	if s.Retention < 0 {
+		return errors.New("retention must not be negative")
+	}
+	return nil
+}
+
+// Feed is a named feed hosted by a Registry.
+type Feed struct {
+	Name     string
+	Settings Settings
+	Repo     *Repo
+	Janitor  *Janitor
+
+	stop context.CancelFunc
+}
+
+// Registry hosts several feeds, each with its own Repo, scheduler and
+// janitor.
+type Registry struct {
+	ctx  context.Context
+	opts []Option
+
+	mu    sync.RWMutex
+	feeds map[string]*Feed
+}
+
+var validFeedName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// NewRegistry returns an empty registry. Every feed's Repo is created with
+// opts, and its background work runs until ctx is done or the feed is
+// deleted.
+func NewRegistry(ctx context.Context, opts ...Option) *Registry {
+	return &Registry{
+		ctx:   ctx,
+		opts:  opts,
+		feeds: map[string]*Feed{},
+	}
+}
+
+// Create adds a feed. Names are lower case letters, digits and dashes.
+func (r *Registry) Create(name string, settings Settings) (Feed, error) {
+	if !validFeedName.MatchString(name) {
+		return Feed{}, fmt.Errorf("invalid feed name %q", name)
+	}
+	if err := settings.validate(); err != nil {
+		return Feed{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.feeds[name]; ok {
+		return Feed{}, ErrFeedExists
+	}
+	repo := New(r.opts...)
+	repo.configure(settings)
+
+	ctx, stop := context.WithCancel(r.ctx)
+	f := &Feed{
+		Name:     name,
+		Settings: settings,
+		Repo:     repo,
+		Janitor:  NewJanitor(repo, time.Minute),
+		stop:     stop,
+	}
+	go NewScheduler(repo, time.Second).Run(ctx)
+	go f.Janitor.Run(ctx)
+
+	r.feeds[name] = f
+	return *f, nil
+}
+
+func (r *Registry) Get(name string) (Feed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.feeds[name]
+	if !ok {
+		return Feed{}, ErrFeedNotFound
+	}
+	return *f, nil
+}
+
+// List returns every feed, sorted by name.
+func (r *Registry) List() []Feed {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	feeds := make([]Feed, 0, len(r.feeds))
+	for _, f := range r.feeds {
+		feeds = append(feeds, *f)
+	}
+	sort.Slice(feeds, func(i, j int) bool {
+		return feeds[i].Name < feeds[j].Name
+	})
+	return feeds
+}
+
+// Update replaces a feed's settings. They apply to items added from now
+// on; items already in the feed keep their status and expiry.
+func (r *Registry) Update(name string, settings Settings) (Feed, error) {
+	if err := settings.validate(); err != nil {
+		return Feed{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.feeds[name]
+	if !ok {
+		return Feed{}, ErrFeedNotFound
+	}
+	f.Settings = settings
+	f.Repo.configure(settings)
+	return *f, nil
+}
+
+// Delete removes a feed and stops its background work.
+func (r *Registry) Delete(name string) error {
+	if name == DefaultFeed {
+		return errors.New("the default feed cannot be deleted")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.feeds[name]
+	if !ok {
+		return ErrFeedNotFound
+	}
+	f.stop()
+	delete(r.feeds, name)
+	return nil
+}
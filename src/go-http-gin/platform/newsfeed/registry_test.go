@@ -0,0 +1,88 @@
+package newsfeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feeds := NewRegistry(ctx)
+
+	if _, err := feeds.Create("Ops Team", Settings{}); err == nil {
+		t.Error("Create accepted an invalid name")
+	}
+	if _, err := feeds.Create("ops", Settings{Visibility: "secret"}); err == nil {
+		t.Error("Create accepted an invalid visibility")
+	}
+
+	ops, err := feeds.Create("ops", Settings{Moderation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops.Settings.Visibility != VisibilityPublic {
+		t.Errorf("visibility = %q, want public", ops.Settings.Visibility)
+	}
+	if _, err := feeds.Create("ops", Settings{}); !errors.Is(err, ErrFeedExists) {
+		t.Errorf("duplicate Create err = %v, want ErrFeedExists", err)
+	}
+	eng, _ := feeds.Create("engineering", Settings{Visibility: VisibilityPrivate})
+
+	// Feeds do not share items.
+	ops.Repo.Add(ctx, Item{Title: "Deploy"})
+	eng.Repo.Add(ctx, Item{Title: "RFC"})
+	if n := len(ops.Repo.Queue(ctx, StatusPending)); n != 1 {
+		t.Errorf("ops queue = %d items, want 1", n)
+	}
+	if items := eng.Repo.GetAll(ctx); len(items) != 1 || items[0].Title != "RFC" {
+		t.Errorf("engineering items = %v", items)
+	}
+
+	if list := feeds.List(); len(list) != 2 || list[0].Name != "engineering" || list[1].Name != "ops" {
+		t.Errorf("List = %+v", list)
+	}
+
+	if _, err := feeds.Update("ops", Settings{}); err != nil {
+		t.Fatal(err)
+	}
+	if item := ops.Repo.Add(ctx, Item{Title: "Rollback"}); item.Status != StatusApproved {
+		t.Errorf("status after disabling moderation = %q", item.Status)
+	}
+	if _, err := feeds.Update("missing", Settings{}); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("Update(missing) err = %v, want ErrFeedNotFound", err)
+	}
+
+	if err := feeds.Delete("ops"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feeds.Get("ops"); !errors.Is(err, ErrFeedNotFound) {
+		t.Errorf("Get after Delete err = %v, want ErrFeedNotFound", err)
+	}
+	feeds.Create(DefaultFeed, Settings{})
+	if err := feeds.Delete(DefaultFeed); err == nil {
+		t.Error("Delete removed the default feed")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithRetention(time.Hour))
+	ctx := context.Background()
+
+	kept := feed.Add(ctx, Item{Title: "Kept", ExpiresAt: epoch.Add(48 * time.Hour)})
+	retained := feed.Add(ctx, Item{Title: "Retained"})
+	scheduled := feed.Add(ctx, Item{Title: "Scheduled", PublishAt: epoch.Add(time.Hour)})
+
+	if !kept.ExpiresAt.Equal(epoch.Add(48 * time.Hour)) {
+		t.Errorf("explicit expiry changed to %v", kept.ExpiresAt)
+	}
+	if !retained.ExpiresAt.Equal(epoch.Add(time.Hour)) {
+		t.Errorf("retained expiry = %v, want %v", retained.ExpiresAt, epoch.Add(time.Hour))
+	}
+	if !scheduled.ExpiresAt.Equal(epoch.Add(2 * time.Hour)) {
+		t.Errorf("scheduled expiry = %v, want an hour after publication", scheduled.ExpiresAt)
+	}
+}
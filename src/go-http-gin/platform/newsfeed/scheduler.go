@@ -0,0 +1,34 @@
+package newsfeed
+
+import (
+	"context"
+	"time"
+)
+
+// Scheduler periodically promotes scheduled items into the feed.
+type Scheduler struct {
+	repo     *Repo
+	interval time.Duration
+}
+
+func NewScheduler(repo *Repo, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+// Run checks for due items every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticks, stop := s.repo.clock.Tick(s.interval)
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			s.repo.PublishDue()
+		}
+	}
+}
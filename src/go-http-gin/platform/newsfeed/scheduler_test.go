@@ -0,0 +1,87 @@
+package newsfeed
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAddScheduledItemIsHidden(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+
+	feed.Add(context.Background(), Item{Title: "Later", PublishAt: epoch.Add(time.Hour)})
+
+	if len(feed.GetAll(context.Background())) != 0 {
+		t.Errorf("Scheduled item is visible before it is due")
+	}
+	if len(feed.Scheduled()) != 1 {
+		t.Errorf("Item was not scheduled")
+	}
+}
+
+func TestAddPastPublishAtIsVisible(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+
+	feed.Add(context.Background(), Item{Title: "Backdated", PublishAt: epoch.Add(-time.Hour)})
+
+	if len(feed.GetAll(context.Background())) != 1 {
+		t.Errorf("Backdated item was not published")
+	}
+}
+
+func TestPublishDue(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	feed.Add(context.Background(), Item{Title: "First", PublishAt: epoch.Add(time.Minute)})
+	feed.Add(context.Background(), Item{Title: "Second", PublishAt: epoch.Add(time.Hour)})
+
+	clock.Advance(time.Minute)
+	due := feed.PublishDue()
+
+	if len(due) != 1 || due[0].Title != "First" {
+		t.Fatalf("PublishDue() = %v, want only First", due)
+	}
+	if len(feed.GetAll(context.Background())) != 1 || len(feed.Scheduled()) != 1 {
+		t.Errorf("got %d published and %d scheduled, want 1 and 1",
+			len(feed.GetAll(context.Background())), len(feed.Scheduled()))
+	}
+}
+
+func TestSchedulerPromotesAndNotifies(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	items, cancel := feed.Subscribe()
+	defer cancel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go NewScheduler(feed, time.Minute).Run(ctx)
+	<-clock.ticking
+
+	feed.Add(context.Background(), Item{Title: "Announcement", PublishAt: epoch.Add(90 * time.Second)})
+
+	clock.Advance(time.Minute)
+	// Ticks are unbuffered, so the scheduler only takes a second tick once
+	// it has handled the first.
+	clock.Advance(0)
+	if len(feed.GetAll(context.Background())) != 0 {
+		t.Fatalf("Item published before it was due")
+	}
+
+	clock.Advance(time.Minute)
+	select {
+	case item := <-items:
+		if item.Title != "Announcement" {
+			t.Errorf("got %q, want Announcement", item.Title)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber was not notified")
+	}
+	if len(feed.GetAll(context.Background())) != 1 {
+		t.Errorf("Item was not promoted")
+	}
+}
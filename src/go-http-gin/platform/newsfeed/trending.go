@@ -0,0 +1,173 @@
+package newsfeed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Engagement is a kind of interaction counted towards an item's trending
+// score.
+type Engagement string
+
+const (
+	EngagementReaction Engagement = "reaction"
+	EngagementComment  Engagement = "comment"
+	EngagementView     Engagement = "view"
+)
+
+// engagementWeights is what one interaction of each kind adds to an item's
+// score. Publishing an item counts as postWeight.
+var engagementWeights = map[Engagement]float64{
+	EngagementReaction: 1,
+	EngagementComment:  3,
+	EngagementView:     0.2,
+}
+
+const postWeight = 1
+
+// DefaultHalfLife is how long it takes for an interaction to count half as
+// much towards the trending score.
+const DefaultHalfLife = 6 * time.Hour
+
+type Engager interface {
+	Engage(ctx context.Context, id string, kind Engagement) error
+}
+
+// WithHalfLife sets the decay of trending scores.
+func WithHalfLife(halfLife time.Duration) Option {
+	return func(r *Repo) {
+		r.trend = newTrend(halfLife)
+	}
+}
+
+// Engage records an interaction with a published item.
+func (r *Repo) Engage(ctx context.Context, id string, kind Engagement) error {
+	_, span := tracer().Start(ctx, "Repo.Engage")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("newsfeed.item_id", id),
+		attribute.String("newsfeed.engagement", string(kind)),
+	)
+
+	weight, ok := engagementWeights[kind]
+	if !ok {
+		return fmt.Errorf("unknown engagement %q", kind)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	e, ok := r.trend.byID[id]
+	if !ok || e.item.Expired(now) {
+		return ErrNotFound
+	}
+	r.trend.add(e, weight, now)
+	return nil
+}
+
+// Trending returns the published items that have not expired, highest
+// trending score first.
+func (r *Repo) Trending(ctx context.Context) []Item {
+	_, span := tracer().Start(ctx, "Repo.Trending")
+	defer span.End()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.clock.Now()
+	items := make([]Item, 0, len(r.trend.ranked))
+	for _, e := range r.trend.ranked {
+		if !e.item.Expired(now) {
+			items = append(items, e.item)
+		}
+	}
+	span.SetAttributes(attribute.Int("newsfeed.items", len(items)))
+	return items
+}
+
+// trend keeps published items ordered by a score that decays exponentially
+// with time. Rather than decaying every score as the clock moves, each
+// interaction is weighted by exp(rate*(t-origin)): all scores share the
+// same decay factor at any instant, so their order never changes unless an
+// item is engaged with, and that item only ever moves up.
+type trend struct {
+	rate   float64 // per second
+	origin time.Time
+	ranked []*trendEntry
+	byID   map[string]*trendEntry
+}
+
+type trendEntry struct {
+	item  Item
+	score float64
+	pos   int
+}
+
+// maxExponent keeps scores far from overflowing; past it the origin is
+// moved forward and every score rescaled.
+const maxExponent = 500
+
+func newTrend(halfLife time.Duration) *trend {
+	if halfLife <= 0 {
+		halfLife = DefaultHalfLife
+	}
+	return &trend{
+		rate: math.Ln2 / halfLife.Seconds(),
+		byID: map[string]*trendEntry{},
+	}
+}
+
+func (t *trend) insert(item Item, now time.Time) {
+	if t.origin.IsZero() {
+		t.origin = now
+	}
+	e := &trendEntry{item: item, pos: len(t.ranked)}
+	t.ranked = append(t.ranked, e)
+	t.byID[item.ID] = e
+	t.add(e, postWeight, now)
+}
+
+func (t *trend) add(e *trendEntry, weight float64, now time.Time) {
+	exp := t.rate * now.Sub(t.origin).Seconds()
+	if exp > maxExponent {
+		t.rebase(now)
+		exp = 0
+	}
+	e.score += weight * math.Exp(exp)
+
+	for e.pos > 0 && t.ranked[e.pos-1].score < e.score {
+		above := t.ranked[e.pos-1]
+		t.ranked[e.pos], t.ranked[e.pos-1] = above, e
+		above.pos, e.pos = e.pos, e.pos-1
+	}
+}
+
+func (t *trend) rebase(now time.Time) {
+	factor := math.Exp(-t.rate * now.Sub(t.origin).Seconds())
+	for _, e := range t.ranked {
+		e.score *= factor
+	}
+	t.origin = now
+}
+
+// removeExpired drops expired items, keeping the others in order.
+func (t *trend) removeExpired(now time.Time) {
+	live := t.ranked[:0]
+	for _, e := range t.ranked {
+		if e.item.Expired(now) {
+			delete(t.byID, e.item.ID)
+			continue
+		}
+		e.pos = len(live)
+		live = append(live, e)
+	}
+	for i := len(live); i < len(t.ranked); i++ {
+		t.ranked[i] = nil
+	}
+	t.ranked = live
+}
@@ -0,0 +1,144 @@
+package newsfeed
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func titles(items []Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Title)
+	}
+	return out
+}
+
+func assertTitles(t *testing.T, items []Item, want ...string) {
+	t.Helper()
+	got := titles(items)
+	if len(got) != len(want) {
+		t.Fatalf("titles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("titles = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestTrendingFavoursNewerItems(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithHalfLife(time.Hour))
+	ctx := context.Background()
+
+	feed.Add(ctx, Item{Title: "Old"})
+	clock.Advance(time.Minute)
+	feed.Add(ctx, Item{Title: "New"})
+
+	assertTitles(t, feed.Trending(ctx), "New", "Old")
+	assertTitles(t, feed.GetAll(ctx), "Old", "New")
+}
+
+func TestTrendingEngagement(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithHalfLife(time.Hour))
+	ctx := context.Background()
+
+	a := feed.Add(ctx, Item{Title: "A"})
+	clock.Advance(time.Hour)
+	b := feed.Add(ctx, Item{Title: "B"})
+
+	// A was posted an hour earlier, so one reaction only brings it level.
+	feed.Engage(ctx, a.ID, EngagementReaction)
+	feed.Engage(ctx, a.ID, EngagementView)
+	assertTitles(t, feed.Trending(ctx), "A", "B")
+
+	// A comment on B outweighs the reaction and view on A.
+	feed.Engage(ctx, b.ID, EngagementComment)
+	assertTitles(t, feed.Trending(ctx), "B", "A")
+
+	// Three hours on, B's comment counts for an eighth, and a single
+	// reaction puts A back on top.
+	clock.Advance(3 * time.Hour)
+	feed.Engage(ctx, a.ID, EngagementReaction)
+	assertTitles(t, feed.Trending(ctx), "A", "B")
+}
+
+func TestTrendingDecay(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithHalfLife(time.Hour))
+	ctx := context.Background()
+
+	popular := feed.Add(ctx, Item{Title: "Popular"})
+	for i := 0; i < 100; i++ {
+		feed.Engage(ctx, popular.ID, EngagementComment)
+	}
+
+	// 301 points halve to under 1 after nine half-lives.
+	clock.Advance(8 * time.Hour)
+	feed.Add(ctx, Item{Title: "Fresh"})
+	assertTitles(t, feed.Trending(ctx), "Popular", "Fresh")
+
+	clock.Advance(time.Hour)
+	feed.Add(ctx, Item{Title: "Fresher"})
+	assertTitles(t, feed.Trending(ctx), "Fresher", "Popular", "Fresh")
+}
+
+func TestTrendingRebase(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock), WithHalfLife(time.Minute))
+	ctx := context.Background()
+
+	a := feed.Add(ctx, Item{Title: "A"})
+	b := feed.Add(ctx, Item{Title: "B"})
+
+	// A day of one-minute half-lives is far beyond what float64 can hold
+	// without moving the origin.
+	for i := 0; i < 24; i++ {
+		clock.Advance(time.Hour)
+		feed.Engage(ctx, b.ID, EngagementView)
+		feed.Engage(ctx, a.ID, EngagementReaction)
+	}
+	assertTitles(t, feed.Trending(ctx), "A", "B")
+	for _, e := range feed.trend.ranked {
+		if math.IsInf(e.score, 0) || math.IsNaN(e.score) {
+			t.Fatalf("score of %s overflowed: %v", e.item.Title, e.score)
+		}
+	}
+}
+
+func TestTrendingSkipsExpiredAndUnpublished(t *testing.T) {
+	clock := newFakeClock(epoch)
+	feed := New(WithClock(clock))
+	ctx := context.Background()
+
+	short := feed.Add(ctx, Item{Title: "Short", ExpiresAt: epoch.Add(time.Minute)})
+	later := feed.Add(ctx, Item{Title: "Later", PublishAt: epoch.Add(time.Hour)})
+	feed.Add(ctx, Item{Title: "Kept"})
+
+	if err := feed.Engage(ctx, later.ID, EngagementView); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Engage(scheduled) err = %v, want ErrNotFound", err)
+	}
+
+	clock.Advance(time.Minute)
+	assertTitles(t, feed.Trending(ctx), "Kept")
+	if err := feed.Engage(ctx, short.ID, EngagementView); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Engage(expired) err = %v, want ErrNotFound", err)
+	}
+
+	feed.PurgeExpired()
+	if len(feed.trend.ranked) != 1 || len(feed.trend.byID) != 1 {
+		t.Errorf("trend still holds %d items after purge", len(feed.trend.ranked))
+	}
+
+	clock.Advance(time.Hour)
+	feed.PublishDue()
+	assertTitles(t, feed.Trending(ctx), "Later", "Kept")
+
+	if err := feed.Engage(ctx, later.ID, "like"); err == nil {
+		t.Error("Engage with an unknown kind succeeded")
+	}
+}
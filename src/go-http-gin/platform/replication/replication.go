@@ -0,0 +1,256 @@
+// Package replication keeps the feeds of several newsfeeder instances in
+// step over HTTP.
+//
+// There is no leader. Every instance pushes items to its peers as they are
+// published and periodically pulls each peer's full state. Feeds merge as
+// grow-only sets keyed by item ID (see newsfeed.Repo.Merge), so pushes and
+// pulls can be repeated, reordered or lost without the instances diverging:
+// once a partition heals, the next pull brings them back together.
+//
+// Every feed in the registry is replicated, matched by name. Feeds
+// themselves are not: a feed must be created on each instance that should
+// hold a copy of it.
+package replication
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"newsfeeder/platform/newsfeed"
+)
+
+// ItemsPath is where instances serve and accept replicated items.
+const ItemsPath = "/admin/replication/items"
+
+// Feeds is the part of newsfeed.Registry used for replication.
+type Feeds interface {
+	newsfeed.Replicated
+	List() []newsfeed.Feed
+}
+
+// Node replicates a registry of feeds with a fixed set of peers.
+type Node struct {
+	feeds    Feeds
+	peers    []string
+	client   *http.Client
+	token    string
+	interval time.Duration
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats counts replication traffic.
+type Stats struct {
+	Pushed   int64     `json:"pushed"`
+	Pulled   int64     `json:"pulled"`
+	Merged   int64     `json:"merged"`
+	Failures int64     `json:"failures"`
+	LastSync time.Time `json:"last_sync,omitzero"`
+}
+
+// Option configures a Node created with NewNode.
+type Option func(*Node)
+
+func WithClient(client *http.Client) Option {
+	return func(n *Node) {
+		n.client = client
+	}
+}
+
+// WithToken authenticates to peers with token as a bearer token.
+func WithToken(token string) Option {
+	return func(n *Node) {
+		n.token = token
+	}
+}
+
+// WithInterval sets how often Run pulls from every peer.
+func WithInterval(interval time.Duration) Option {
+	return func(n *Node) {
+		n.interval = interval
+	}
+}
+
+// NewNode returns a node replicating feeds with peers, given as base URLs
+// such as "http://10.0.0.2:8080".
+func NewNode(feeds Feeds, peers []string, opts ...Option) *Node {
+	n := &Node{
+		feeds:    feeds,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: 10 * time.Second,
+	}
+	for _, peer := range peers {
+		n.peers = append(n.peers, strings.TrimRight(peer, "/"))
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Run pushes published items to the peers and pulls from them every
+// interval until ctx is cancelled. Feeds created while it runs are watched
+// from the next pull on; until then, pulls carry their items.
+func (n *Node) Run(ctx context.Context) {
+	published := make(chan feedItem)
+	watched := map[*newsfeed.Repo]func(){}
+	defer func() {
+		for _, unsubscribe := range watched {
+			unsubscribe()
+		}
+	}()
+
+	n.watch(ctx, watched, published)
+	n.Sync(ctx)
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-published:
+			n.Push(ctx, p.feed, []newsfeed.Item{p.item})
+		case <-ticker.C:
+			n.watch(ctx, watched, published)
+			n.Sync(ctx)
+		}
+	}
+}
+
+type feedItem struct {
+	feed string
+	item newsfeed.Item
+}
+
+// watch subscribes to the feeds that are not watched yet and forwards
+// their items to published, and unsubscribes from deleted feeds.
+func (n *Node) watch(ctx context.Context, watched map[*newsfeed.Repo]func(), published chan<- feedItem) {
+	current := map[*newsfeed.Repo]bool{}
+	for _, f := range n.feeds.List() {
+		current[f.Repo] = true
+		if _, ok := watched[f.Repo]; ok {
+			continue
+		}
+		items, unsubscribe := f.Repo.Subscribe()
+		watched[f.Repo] = unsubscribe
+		go func(name string) {
+			for item := range items {
+				select {
+				case published <- feedItem{feed: name, item: item}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(f.Name)
+	}
+	for repo, unsubscribe := range watched {
+		if !current[repo] {
+			unsubscribe()
+			delete(watched, repo)
+		}
+	}
+}
+
+// Push sends items of the named feed to every peer. Failures are only
+// counted; the peers catch up on their next pull.
+func (n *Node) Push(ctx context.Context, feed string, items []newsfeed.Item) {
+	body, err := json.Marshal(map[string][]newsfeed.Item{feed: items})
+	if err != nil {
+		return
+	}
+	var wg sync.WaitGroup
+	for _, peer := range n.peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+			err := n.send(ctx, http.MethodPost, peer, body, nil)
+			n.record(func(s *Stats) {
+				if err != nil {
+					s.Failures++
+					return
+				}
+				s.Pushed += int64(len(items))
+			})
+			if err != nil {
+				slog.WarnContext(ctx, "replication push", "peer", peer, "error", err)
+			}
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// Sync pulls every peer's items and merges them, returning how many were
+// new. Unreachable peers are skipped.
+func (n *Node) Sync(ctx context.Context) int {
+	merged := 0
+	for _, peer := range n.peers {
+		var feeds map[string][]newsfeed.Item
+		if err := n.send(ctx, http.MethodGet, peer, nil, &feeds); err != nil {
+			n.record(func(s *Stats) { s.Failures++ })
+			slog.WarnContext(ctx, "replication pull", "peer", peer, "error", err)
+			continue
+		}
+		pulled, added := 0, 0
+		for _, items := range feeds {
+			pulled += len(items)
+		}
+		for _, items := range n.feeds.Merge(ctx, feeds) {
+			added += len(items)
+		}
+		merged += added
+		n.record(func(s *Stats) {
+			s.Pulled += int64(pulled)
+			s.Merged += int64(added)
+		})
+	}
+	n.record(func(s *Stats) { s.LastSync = time.Now() })
+	return merged
+}
+
+// Stats returns a snapshot of the replication counters.
+func (n *Node) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+func (n *Node) record(update func(*Stats)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	update(&n.stats)
+}
+
+func (n *Node) send(ctx context.Context, method, peer string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, peer+ItemsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, peer, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
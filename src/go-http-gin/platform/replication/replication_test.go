@@ -0,0 +1,245 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"newsfeeder/httpd/handler"
+	"newsfeeder/platform/newsfeed"
+
+	"github.com/gin-gonic/gin"
+)
+
+const token = "cluster-s3cret"
+
+// partition fails requests to the hosts it has cut off.
+type partition struct {
+	mu  sync.Mutex
+	cut map[string]bool
+}
+
+func (p *partition) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.mu.Lock()
+	cut := p.cut[req.URL.Host]
+	p.mu.Unlock()
+	if cut {
+		return nil, errors.New("network partition")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (p *partition) set(cut ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cut = map[string]bool{}
+	for _, u := range cut {
+		parsed, _ := url.Parse(u)
+		p.cut[parsed.Host] = true
+	}
+}
+
+type instance struct {
+	url   string
+	feeds *newsfeed.Registry
+	feed  *newsfeed.Repo // the default feed
+	node  *Node
+}
+
+// newCluster starts n in-process servers, each hosting the default feed,
+// replicating with each other through net.
+func newCluster(t *testing.T, n int, net *partition) []*instance {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cluster := make([]*instance, n)
+	for i := range cluster {
+		feeds := newsfeed.NewRegistry(ctx)
+		feed, err := feeds.Create(newsfeed.DefaultFeed, newsfeed.Settings{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := gin.New()
+		admin := r.Group("/admin", handler.RequireToken(token))
+		admin.GET("/replication/items", handler.ReplicationItemsGet(feeds))
+		admin.POST("/replication/items", handler.ReplicationItemsPost(feeds))
+		srv := httptest.NewServer(r)
+		t.Cleanup(srv.Close)
+		cluster[i] = &instance{url: srv.URL, feeds: feeds, feed: feed.Repo}
+	}
+	for i, in := range cluster {
+		var peers []string
+		for j, peer := range cluster {
+			if i != j {
+				peers = append(peers, peer.url)
+			}
+		}
+		in.node = NewNode(in.feeds, peers,
+			WithClient(&http.Client{Transport: net}),
+			WithToken(token),
+			WithInterval(time.Hour))
+	}
+	return cluster
+}
+
+func ids(feed *newsfeed.Repo) []string {
+	var out []string
+	for _, item := range feed.Export(context.Background()) {
+		out = append(out, item.ID)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPushReplicatesPublishedItems(t *testing.T) {
+	cluster := newCluster(t, 3, &partition{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, in := range cluster {
+		go in.node.Run(ctx)
+	}
+
+	// Give every node time to subscribe before publishing.
+	time.Sleep(50 * time.Millisecond)
+	item := cluster[0].feed.Add(ctx, newsfeed.Item{Title: "Hello", Post: "cluster"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for _, in := range cluster[1:] {
+		for {
+			items := in.feed.GetAll(ctx)
+			if len(items) == 1 && items[0].ID == item.ID && items[0].CreatedAt.Equal(item.CreatedAt) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s never received the item: %v", in.url, items)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func TestConvergesAfterPartition(t *testing.T) {
+	net := &partition{}
+	cluster := newCluster(t, 3, net)
+	a, b, c := cluster[0], cluster[1], cluster[2]
+	ctx := context.Background()
+
+	// Cut a off from b and c, then write on both sides.
+	net.set(a.url)
+	itemA := a.feed.Add(ctx, newsfeed.Item{Title: "From a"})
+	itemB := b.feed.Add(ctx, newsfeed.Item{Title: "From b"})
+	itemC := c.feed.Add(ctx, newsfeed.Item{Title: "From c", PublishAt: time.Now().Add(time.Hour)})
+	b.node.Push(ctx, newsfeed.DefaultFeed, []newsfeed.Item{itemB})
+	c.node.Push(ctx, newsfeed.DefaultFeed, []newsfeed.Item{itemC})
+
+	if got := ids(a.feed); !equal(got, []string{itemA.ID}) {
+		t.Fatalf("a saw writes across the partition: %v", got)
+	}
+	if !equal(ids(b.feed), ids(c.feed)) || len(ids(b.feed)) != 2 {
+		t.Fatalf("b and c diverged: %v, %v", ids(b.feed), ids(c.feed))
+	}
+	if b.node.Stats().Failures == 0 {
+		t.Error("pushes to a did not fail")
+	}
+
+	// Heal. One pull each brings every instance up to date, and a second
+	// round changes nothing.
+	net.set()
+	for _, in := range cluster {
+		in.node.Sync(ctx)
+	}
+	want := ids(a.feed)
+	if len(want) != 3 {
+		t.Fatalf("a has %v after healing", want)
+	}
+	for _, in := range cluster {
+		if got := ids(in.feed); !equal(got, want) {
+			t.Errorf("%s has %v, want %v", in.url, got, want)
+		}
+		if n := in.node.Sync(ctx); n != 0 {
+			t.Errorf("second sync merged %d items", n)
+		}
+	}
+
+	// The scheduled item stays scheduled everywhere.
+	for _, in := range cluster {
+		if s := in.feed.Scheduled(); len(s) != 1 || s[0].ID != itemC.ID {
+			t.Errorf("%s scheduled = %v", in.url, s)
+		}
+	}
+}
+
+func TestReplicatesEveryFeed(t *testing.T) {
+	cluster := newCluster(t, 2, &partition{})
+	a, b := cluster[0], cluster[1]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opsA, _ := a.feeds.Create("ops", newsfeed.Settings{})
+	opsB, _ := b.feeds.Create("ops", newsfeed.Settings{})
+	local, _ := a.feeds.Create("local", newsfeed.Settings{})
+	pulled := opsA.Repo.Add(ctx, newsfeed.Item{Title: "Deploy"})
+	local.Repo.Add(ctx, newsfeed.Item{Title: "Only on a"})
+
+	if n := b.node.Sync(ctx); n != 1 {
+		t.Errorf("Sync merged %d items, want 1", n)
+	}
+	if items := opsB.Repo.GetAll(ctx); len(items) != 1 || items[0].ID != pulled.ID {
+		t.Errorf("b's ops feed = %v", items)
+	}
+	if items := b.feed.GetAll(ctx); len(items) != 0 {
+		t.Errorf("b's default feed = %v", items)
+	}
+	if _, err := b.feeds.Get("local"); err == nil {
+		t.Error("Sync created a feed")
+	}
+
+	// Items published to any feed are pushed.
+	go a.node.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+	pushed := opsA.Repo.Add(ctx, newsfeed.Item{Title: "Rollback"})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		items := opsB.Repo.GetAll(ctx)
+		if len(items) == 2 && items[1].ID == pushed.ID {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("b never received the pushed item: %v", items)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReplicationNeedsToken(t *testing.T) {
+	cluster := newCluster(t, 2, &partition{})
+	cluster[0].node.token = "wrong"
+	cluster[1].feed.Add(context.Background(), newsfeed.Item{Title: "Secret"})
+
+	if n := cluster[0].node.Sync(context.Background()); n != 0 {
+		t.Errorf("merged %d items with a bad token", n)
+	}
+	if cluster[0].node.Stats().Failures != 1 {
+		t.Errorf("stats = %+v", cluster[0].node.Stats())
+	}
+}
@@ -0,0 +1,87 @@
+// Package tracing configures OpenTelemetry for the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Config selects where finished spans are written.
+type Config struct {
+	// Exporter is "none", "stderr" or "file". Spans are not written to
+	// stdout, which carries the JSON logs.
+	Exporter string
+	// File is the path spans are appended to by the file exporter.
+	File string
+}
+
+// ConfigFromEnv reads TRACES_EXPORTER (none by default) and TRACES_FILE
+// (traces.json by default).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Exporter: os.Getenv("TRACES_EXPORTER"),
+		File:     os.Getenv("TRACES_FILE"),
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = "none"
+	}
+	if cfg.File == "" {
+		cfg.File = "traces.json"
+	}
+	return cfg
+}
+
+// Setup installs the global tracer provider and the W3C trace context
+// propagator. The returned function flushes and closes the exporter.
+func Setup(cfg Config, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var w io.Writer
+	var closer io.Closer
+	switch cfg.Exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stderr":
+		w = os.Stderr
+	case "file":
+		f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w, closer = f, f
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, want none, stderr or file", cfg.Exporter)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
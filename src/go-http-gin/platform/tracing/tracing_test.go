@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// restoreGlobals puts back the provider and propagator Setup replaces.
+func restoreGlobals(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("TRACES_EXPORTER", "")
+	t.Setenv("TRACES_FILE", "")
+	if cfg := ConfigFromEnv(); cfg != (Config{Exporter: "none", File: "traces.json"}) {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	t.Setenv("TRACES_EXPORTER", "file")
+	t.Setenv("TRACES_FILE", "/tmp/spans.json")
+	if cfg := ConfigFromEnv(); cfg != (Config{Exporter: "file", File: "/tmp/spans.json"}) {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+}
+
+func TestSetupFileExporter(t *testing.T) {
+	restoreGlobals(t)
+	path := filepath.Join(t.TempDir(), "traces.json")
+
+	shutdown, err := Setup(Config{Exporter: "file", File: path}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "work")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Name":"work"`) {
+		t.Errorf("Exported spans %s do not include the span", data)
+	}
+}
+
+func TestSetupWithoutExporter(t *testing.T) {
+	restoreGlobals(t)
+	provider := otel.GetTracerProvider()
+
+	shutdown, err := Setup(Config{Exporter: "none"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if otel.GetTracerProvider() != provider {
+		t.Errorf("Setup installed a tracer provider without an exporter")
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	restoreGlobals(t)
+	for _, exporter := range []string{"stdout", "jaeger"} {
+		if _, err := Setup(Config{Exporter: exporter}, "test"); err == nil {
+			t.Errorf("Setup accepted exporter %q", exporter)
+		}
+	}
+}
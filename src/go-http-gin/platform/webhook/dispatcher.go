@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Delivery records one attempt to deliver an event to a subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Attempt        int       `json:"attempt"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// DeadLetter is an event that could not be delivered after every retry.
+type DeadLetter struct {
+	SubscriptionID string    `json:"subscription_id"`
+	URL            string    `json:"url"`
+	Event          Event     `json:"event"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	FailedAt       time.Time `json:"failed_at"`
+}
+
+// Option configures a Dispatcher created with NewDispatcher.
+type Option func(*Dispatcher)
+
+func WithClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithRetries sets how many attempts are made per delivery and the delay
+// before the second one; each further retry doubles the delay.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = attempts
+		d.backoff = backoff
+	}
+}
+
+// Dispatcher delivers events to every interested subscription, retrying
+// failures with exponential backoff until it is closed.
+type Dispatcher struct {
+	registry    *Registry
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxLog      int
+
+	ctx         context.Context
+	stop        context.CancelFunc
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	closed      bool
+	deliveries  []Delivery
+	deadLetters []DeadLetter
+}
+
+func NewDispatcher(registry *Registry, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		registry:    registry,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: 5,
+		backoff:     time.Second,
+		maxLog:      1000,
+	}
+	d.ctx, d.stop = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Publish starts delivering event to the subscriptions that want it and
+// returns without waiting for the receivers.
+func (d *Dispatcher) Publish(event Event) {
+	// Close takes d.mu too, so it cannot start waiting between the check
+	// and wg.Add.
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	for _, sub := range d.registry.List() {
+		if !sub.Wants(event.Type) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(sub Subscription) {
+			defer d.wg.Done()
+			d.deliver(sub, event)
+		}(sub)
+	}
+}
+
+// Wait blocks until every delivery in flight has succeeded or been
+// dead-lettered.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Close abandons the deliveries in flight, cancelling their requests and
+// pending retries, and waits for them to return. Events published
+// afterwards are dropped.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.stop()
+	d.wg.Wait()
+}
+
+// Deliveries returns the most recent delivery attempts, oldest first.
+func (d *Dispatcher) Deliveries() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Delivery(nil), d.deliveries...)
+}
+
+// DeadLetters returns the most recent dead letters, oldest first.
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DeadLetter(nil), d.deadLetters...)
+}
+
+func (d *Dispatcher) deliver(sub Subscription, event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.deadLetter(sub, event, 0, err)
+		return
+	}
+
+	delay := d.backoff
+	for attempt := 1; ; attempt++ {
+		id := newID()
+		status, err := d.post(id, sub, event, body)
+		if d.ctx.Err() != nil {
+			return
+		}
+		d.record(Delivery{
+			ID:             id,
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Attempt:        attempt,
+			StatusCode:     status,
+			Error:          errorString(err),
+			Time:           time.Now(),
+		})
+		if err == nil {
+			return
+		}
+		if attempt >= d.maxAttempts {
+			d.deadLetter(sub, event, attempt, err)
+			return
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-d.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// post makes the delivery attempt id, sent as X-Newsfeed-Delivery so that
+// receivers can match it to the delivery log.
+func (d *Dispatcher) post(id string, sub Subscription, event Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Newsfeed-Event", event.Type)
+	req.Header.Set("X-Newsfeed-Delivery", id)
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) record(delivery Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deliveries = append(d.deliveries, delivery)
+	if len(d.deliveries) > d.maxLog {
+		d.deliveries = d.deliveries[len(d.deliveries)-d.maxLog:]
+	}
+}
+
+func (d *Dispatcher) deadLetter(sub Subscription, event Event, attempts int, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.deadLetters = append(d.deadLetters, DeadLetter{
+		SubscriptionID: sub.ID,
+		URL:            sub.URL,
+		Event:          event,
+		Attempts:       attempts,
+		LastError:      err.Error(),
+		FailedAt:       time.Now(),
+	})
+	if len(d.deadLetters) > d.maxLog {
+		d.deadLetters = d.deadLetters[len(d.deadLetters)-d.maxLog:]
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
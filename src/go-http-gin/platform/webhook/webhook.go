@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventItemCreated is sent when a newsfeed item becomes visible.
+const EventItemCreated = "item.created"
+
+// SignatureHeader carries the HMAC-SHA256 of the request body, keyed with
+// the subscription secret, as "sha256=<hex>".
+const SignatureHeader = "X-Newsfeed-Signature"
+
+var ErrNotFound = errors.New("webhook subscription not found")
+
+// Subscription is a receiver registered for one or more event types.
+type Subscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Wants reports whether the subscription asked for the event type.
+func (s Subscription) Wants(eventType string) bool {
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the JSON body delivered to subscribers.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+func NewEvent(eventType string, data interface{}) Event {
+	return Event{
+		ID:         newID(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       data,
+	}
+}
+
+// Sign returns the signature header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature matches body under secret.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Registry holds the webhook subscriptions.
+type Registry struct {
+	mu   sync.RWMutex
+	subs map[string]Subscription
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		subs: map[string]Subscription{},
+	}
+}
+
+// Add validates and stores sub, filling in its ID, a generated secret if
+// none was given and the default event list.
+func (r *Registry) Add(sub Subscription) (Subscription, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, errors.New("url must be an absolute http or https URL")
+	}
+	if sub.Secret == "" {
+		sub.Secret = newID() + newID()
+	}
+	if len(sub.Events) == 0 {
+		sub.Events = []string{EventItemCreated}
+	}
+	sub.ID = newID()
+	sub.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[sub.ID] = sub
+	return sub, nil
+}
+
+func (r *Registry) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.subs, id)
+	return nil
+}
+
+// List returns every subscription, oldest first.
+func (r *Registry) List() []Subscription {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make([]Subscription, 0, len(r.subs))
+	for _, sub := range r.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+	})
+	return subs
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is an httptest server that records the requests it accepts and
+// fails the first `failures` of them.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func newReceiver(failures int) *receiver {
+	rec := &receiver{failures: failures}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if rec.failures > 0 {
+			rec.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rec.bodies = append(rec.bodies, body)
+		rec.headers = append(rec.headers, r.Header.Clone())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return rec
+}
+
+func TestRegistryAddValidatesURL(t *testing.T) {
+	reg := NewRegistry()
+	if _, err := reg.Add(Subscription{URL: "not a url"}); err == nil {
+		t.Errorf("Add accepted an invalid URL")
+	}
+
+	sub, err := reg.Add(Subscription{URL: "http://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID == "" || sub.Secret == "" || !sub.Wants(EventItemCreated) {
+		t.Errorf("Add did not fill in defaults: %+v", sub)
+	}
+}
+
+func TestRegistryRemove(t *testing.T) {
+	reg := NewRegistry()
+	sub, _ := reg.Add(Subscription{URL: "http://example.com/hook"})
+
+	if err := reg.Remove(sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Remove(sub.ID); err != ErrNotFound {
+		t.Errorf("Remove() = %v, want ErrNotFound", err)
+	}
+	if len(reg.List()) != 0 {
+		t.Errorf("Subscription was not removed")
+	}
+}
+
+func TestDispatcherSignsDelivery(t *testing.T) {
+	rec := newReceiver(0)
+	defer rec.Close()
+	reg := NewRegistry()
+	sub, _ := reg.Add(Subscription{URL: rec.URL, Secret: "s3cret"})
+	d := NewDispatcher(reg)
+
+	d.Publish(NewEvent(EventItemCreated, map[string]string{"title": "Hello"}))
+	d.Wait()
+
+	if len(rec.bodies) != 1 {
+		t.Fatalf("Receiver got %d deliveries, want 1", len(rec.bodies))
+	}
+	if !Verify(sub.Secret, rec.bodies[0], rec.headers[0].Get(SignatureHeader)) {
+		t.Errorf("Signature %q does not match body", rec.headers[0].Get(SignatureHeader))
+	}
+	var event Event
+	if err := json.Unmarshal(rec.bodies[0], &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventItemCreated {
+		t.Errorf("Event type = %q, want %q", event.Type, EventItemCreated)
+	}
+}
+
+func TestDispatcherSkipsUninterestedSubscriptions(t *testing.T) {
+	rec := newReceiver(0)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL, Events: []string{"item.deleted"}})
+	d := NewDispatcher(reg)
+
+	d.Publish(NewEvent(EventItemCreated, nil))
+	d.Wait()
+
+	if len(rec.bodies) != 0 || len(d.Deliveries()) != 0 {
+		t.Errorf("Event was delivered to a subscription that did not want it")
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	rec := newReceiver(2)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg, WithRetries(5, 10*time.Millisecond))
+
+	start := time.Now()
+	d.Publish(NewEvent(EventItemCreated, nil))
+	d.Wait()
+
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Retries took %v, want at least 10ms+20ms of backoff", elapsed)
+	}
+	deliveries := d.Deliveries()
+	if len(deliveries) != 3 {
+		t.Fatalf("Logged %d attempts, want 3", len(deliveries))
+	}
+	if deliveries[0].StatusCode != http.StatusServiceUnavailable || deliveries[2].Error != "" {
+		t.Errorf("Unexpected delivery log: %+v", deliveries)
+	}
+	if len(d.DeadLetters()) != 0 {
+		t.Errorf("Successful delivery was dead-lettered")
+	}
+}
+
+func TestDispatcherDeadLettersAfterMaxAttempts(t *testing.T) {
+	rec := newReceiver(10)
+	defer rec.Close()
+	reg := NewRegistry()
+	sub, _ := reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg, WithRetries(3, time.Millisecond))
+
+	d.Publish(NewEvent(EventItemCreated, nil))
+	d.Wait()
+
+	dead := d.DeadLetters()
+	if len(dead) != 1 {
+		t.Fatalf("Got %d dead letters, want 1", len(dead))
+	}
+	if dead[0].SubscriptionID != sub.ID || dead[0].Attempts != 3 {
+		t.Errorf("Unexpected dead letter: %+v", dead[0])
+	}
+}
+
+func TestDispatcherSendsDeliveryID(t *testing.T) {
+	rec := newReceiver(0)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg)
+
+	event := NewEvent(EventItemCreated, nil)
+	d.Publish(event)
+	d.Wait()
+
+	deliveries := d.Deliveries()
+	if len(deliveries) != 1 || len(rec.headers) != 1 {
+		t.Fatalf("Logged %d deliveries, receiver got %d", len(deliveries), len(rec.headers))
+	}
+	if got := rec.headers[0].Get("X-Newsfeed-Delivery"); got != deliveries[0].ID || got == event.ID {
+		t.Errorf("X-Newsfeed-Delivery = %q, want the delivery ID %q", got, deliveries[0].ID)
+	}
+}
+
+func TestDispatcherCapsDeadLetters(t *testing.T) {
+	rec := newReceiver(10)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg, WithRetries(1, 0))
+	d.maxLog = 2
+
+	for i := 0; i < 3; i++ {
+		d.Publish(NewEvent(EventItemCreated, i))
+		d.Wait()
+	}
+
+	dead := d.DeadLetters()
+	if len(dead) != 2 || dead[0].Event.Data != 1 {
+		t.Errorf("Dead letters = %+v, want the last 2", dead)
+	}
+}
+
+func TestDispatcherCloseStopsRetries(t *testing.T) {
+	rec := newReceiver(10)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg, WithRetries(5, time.Hour))
+
+	d.Publish(NewEvent(EventItemCreated, nil))
+	for len(d.Deliveries()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	closed := make(chan struct{})
+	go func() {
+		d.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the retry backoff")
+	}
+
+	d.Publish(NewEvent(EventItemCreated, nil))
+	d.Wait()
+	if n := len(d.Deliveries()); n != 1 {
+		t.Errorf("Logged %d attempts, want only the one before Close", n)
+	}
+}
+
+func TestDispatcherPublishRacingClose(t *testing.T) {
+	rec := newReceiver(0)
+	defer rec.Close()
+	reg := NewRegistry()
+	reg.Add(Subscription{URL: rec.URL})
+	d := NewDispatcher(reg)
+
+	publishing := make(chan struct{})
+	go func() {
+		defer close(publishing)
+		for i := 0; i < 100; i++ {
+			d.Publish(NewEvent(EventItemCreated, nil))
+		}
+	}()
+	d.Close()
+	after := len(d.Deliveries())
+	<-publishing
+
+	if n := len(d.Deliveries()); n != after {
+		t.Errorf("Logged %d deliveries after Close returned", n-after)
+	}
+}